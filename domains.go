@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+/* ============================== domains subcommand ============================== */
+
+// domainsCmd implements dcrawl-style unique-apex-domain discovery: a plain
+// net/http breadth-first walk (no colly, no JS rendering) that HEAD-checks
+// every URL for text/html before ever GETting it, caps how much of each
+// body it reads, and branches out capped per-hostname and per-apex so a
+// handful of seeds can't explode into millions of URLs off one
+// subdomain-farm host. Useful for building recon seed lists rather than
+// fully crawling any one of them.
+var domainsCmd = &cobra.Command{
+	Use:   "domains",
+	Short: "Breadth-first unique-apex-domain discovery from one or more seed URLs",
+	Run:   runDomains,
+}
+
+func init() {
+	domainsCmd.Flags().StringP("site", "s", "", "Single seed URL or bare domain")
+	domainsCmd.Flags().StringP("seeds", "S", "", "File of seed URLs/domains (one per line)")
+	domainsCmd.Flags().StringP("output", "o", "", "File to append discovered apex domains to; re-read on start so apex domains already in it are not printed or appended again")
+	domainsCmd.Flags().Int("max-body", 1<<20, "Max bytes read per response body")
+	domainsCmd.Flags().Int("max-links-per-host", 50, "Max <a href> matches examined on any one hostname's pages, across all its pages (branching factor); already-seen links count too")
+	domainsCmd.Flags().Int("max-subdomains-per-apex", 20, "Max distinct subdomains explored per apex domain (blogspot-style subdomain explosion guard)")
+	domainsCmd.Flags().IntP("concurrent", "c", 10, "Max concurrent fetches")
+	domainsCmd.Flags().IntP("timeout", "m", 10, "Request timeout (seconds)")
+	commands.AddCommand(domainsCmd)
+}
+
+var hrefAttrRE = regexp.MustCompile(`(?i)<a\b[^>]*\bhref\s*=\s*["']([^"'#]+)["']`)
+
+func runDomains(cmd *cobra.Command, _ []string) {
+	var seedList []string
+	if site, _ := cmd.Flags().GetString("site"); site != "" {
+		seedList = append(seedList, site)
+	}
+	if seedsFile, _ := cmd.Flags().GetString("seeds"); seedsFile != "" {
+		seedList = append(seedList, ReadingLines(seedsFile)...)
+	}
+	if stat, _ := os.Stdin.Stat(); (stat.Mode() & os.ModeCharDevice) == 0 {
+		sc := bufio.NewScanner(os.Stdin)
+		for sc.Scan() {
+			if t := strings.TrimSpace(sc.Text()); t != "" {
+				seedList = append(seedList, t)
+			}
+		}
+	}
+	if len(seedList) == 0 {
+		Logger.Info("No seed in list. Please check your --site/--seeds input")
+		os.Exit(1)
+	}
+
+	maxBody, _ := cmd.Flags().GetInt("max-body")
+	maxLinksPerHost, _ := cmd.Flags().GetInt("max-links-per-host")
+	maxSubsPerApex, _ := cmd.Flags().GetInt("max-subdomains-per-apex")
+	concurrent, _ := cmd.Flags().GetInt("concurrent")
+	timeout, _ := cmd.Flags().GetInt("timeout")
+
+	dd := newDomainDiscoverer(maxBody, maxLinksPerHost, maxSubsPerApex, concurrent, timeout)
+
+	outputFile, _ := cmd.Flags().GetString("output")
+	var out *os.File
+	if outputFile != "" {
+		for _, apex := range ReadingLines(outputFile) {
+			dd.apexSeen.Duplicate(apex) // seed as already-known; don't re-emit
+		}
+		f, err := os.OpenFile(outputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.ModePerm)
+		if err != nil {
+			Logger.Errorf("Failed to open --output %s: %s", outputFile, err)
+		} else {
+			out = f
+			defer out.Close()
+		}
+	}
+	dd.onApex = func(apex string) {
+		fmt.Println(apex)
+		if out != nil {
+			_, _ = out.WriteString(apex + "\n")
+		}
+	}
+
+	for _, s := range seedList {
+		dd.Enqueue(s)
+	}
+	dd.Wait()
+	Logger.Info("Done.")
+}
+
+// domainDiscoverer is the breadth-first walker behind the domains subcommand.
+// Every discovered outlink spawns its own goroutine gated by sem, with wg
+// tracking in-flight work so Wait() returns once the frontier is exhausted.
+type domainDiscoverer struct {
+	client          *http.Client
+	maxBody         int64
+	maxLinksPerHost int
+	maxSubsPerApex  int
+
+	sem chan struct{}
+	wg  sync.WaitGroup
+
+	urlSeen   *StringFilter
+	apexSeen  *StringFilter
+	badHosts  sync.Map // host -> struct{}, never returned a 2xx
+	goodHosts sync.Map // host -> struct{}, returned a 2xx at least once
+	hostLinks sync.Map // host -> *int32, cumulative <a href> matches examined
+	apexSubs  sync.Map // apex -> *StringFilter of subdomains seen under it
+	subAllow  sync.Map // host -> bool, whether its apex's cap admitted it
+
+	onApex func(apex string)
+}
+
+func newDomainDiscoverer(maxBody, maxLinksPerHost, maxSubsPerApex, concurrent, timeoutSec int) *domainDiscoverer {
+	return &domainDiscoverer{
+		client:          &http.Client{Timeout: time.Duration(timeoutSec) * time.Second},
+		maxBody:         int64(maxBody),
+		maxLinksPerHost: maxLinksPerHost,
+		maxSubsPerApex:  maxSubsPerApex,
+		sem:             make(chan struct{}, concurrent),
+		urlSeen:         NewStringFilter(),
+		apexSeen:        NewStringFilter(),
+	}
+}
+
+// Enqueue normalizes raw (adding https:// to bare domains) and schedules it
+// for a visit, skipping anything already seen.
+func (d *domainDiscoverer) Enqueue(raw string) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return
+	}
+	if d.urlSeen.Duplicate(u.String()) {
+		return
+	}
+	d.wg.Add(1)
+	go d.visit(u)
+}
+
+func (d *domainDiscoverer) Wait() { d.wg.Wait() }
+
+func (d *domainDiscoverer) visit(u *url.URL) {
+	defer d.wg.Done()
+	d.sem <- struct{}{}
+	defer func() { <-d.sem }()
+
+	host := u.Hostname()
+	if _, bad := d.badHosts.Load(host); bad {
+		if _, good := d.goodHosts.Load(host); !good {
+			return
+		}
+	}
+
+	// HEAD first: only commit to a GET (and its body-read cap) for pages
+	// that actually advertise themselves as HTML.
+	head, err := d.client.Head(u.String())
+	if err != nil || head.StatusCode < 200 || head.StatusCode >= 300 {
+		d.badHosts.Store(host, struct{}{})
+		return
+	}
+	_ = head.Body.Close()
+	if !strings.Contains(strings.ToLower(head.Header.Get("Content-Type")), "text/html") {
+		return
+	}
+
+	resp, err := d.client.Get(u.String())
+	if err != nil {
+		d.badHosts.Store(host, struct{}{})
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		d.badHosts.Store(host, struct{}{})
+		return
+	}
+	d.goodHosts.Store(host, struct{}{})
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, d.maxBody))
+
+	if apex := GetDomain(u); apex != "" {
+		if !d.apexSeen.Duplicate(apex) && d.onApex != nil {
+			d.onApex(apex)
+		}
+		if !d.allowSubdomainBranch(apex, host) {
+			return
+		}
+	}
+
+	for _, m := range hrefAttrRE.FindAllStringSubmatch(string(body), -1) {
+		if !d.allowHostBranch(host) {
+			break
+		}
+		abs := FixUrl(u, strings.TrimSpace(m[1]))
+		if abs == "" {
+			continue
+		}
+		next, err := url.Parse(abs)
+		if err != nil || (next.Scheme != "http" && next.Scheme != "https") {
+			continue
+		}
+		if d.urlSeen.Duplicate(next.String()) {
+			continue
+		}
+		d.wg.Add(1)
+		go d.visit(next)
+	}
+}
+
+// allowHostBranch enforces --max-links-per-host: a cumulative cap on
+// <a href> matches examined on sourceHost's pages, across every page of
+// its we visit (not just the current one). Matches that turn out to be
+// already-seen, unparseable or non-http(s) count against the cap too.
+func (d *domainDiscoverer) allowHostBranch(sourceHost string) bool {
+	v, _ := d.hostLinks.LoadOrStore(sourceHost, new(int32))
+	return atomic.AddInt32(v.(*int32), 1) <= int32(d.maxLinksPerHost)
+}
+
+// allowSubdomainBranch enforces --max-subdomains-per-apex: once an apex
+// has accumulated that many distinct subdomains, pages on any further new
+// subdomain are recorded under the apex tally but not crawled for outlinks.
+func (d *domainDiscoverer) allowSubdomainBranch(apex, host string) bool {
+	if v, ok := d.subAllow.Load(host); ok {
+		return v.(bool)
+	}
+	v, _ := d.apexSubs.LoadOrStore(apex, NewStringFilter())
+	sf := v.(*StringFilter)
+	sf.Duplicate(host)
+	allowed, _ := d.subAllow.LoadOrStore(host, sf.Len() <= d.maxSubsPerApex)
+	return allowed.(bool)
+}
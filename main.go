@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	jsoniter "github.com/json-iterator/go"
 	"io"
@@ -27,11 +28,19 @@ var commands = &cobra.Command{
 	Run:  run,
 }
 
-func main() {
+// registerFlags declares every flag the root command understands. Split out
+// from main() so tests can build a *cobra.Command with the real flag set
+// (defaults included) without going through os.Args/Execute.
+func registerFlags(commands *cobra.Command) {
 	commands.Flags().StringP("site", "s", "", "Site or bare domain to crawl (e.g., https://example.com or example.com)")
 	commands.Flags().StringP("sites", "S", "", "File of sites/domains to crawl (one per line)")
 	commands.Flags().StringP("proxy", "p", "", "Proxy (Ex: http://127.0.0.1:8080)")
 	commands.Flags().StringP("output", "o", "", "Output folder")
+	commands.Flags().String("warc", "", "Write every request/response to a WARC 1.1 file at this path (.gz suffix gzips each record)")
+	commands.Flags().String("wacz", "", "Also bundle the WARC plus a page index into a WACZ file at this path on exit (requires --warc)")
+	commands.Flags().String("queue", "", "Visit queue backend: empty/\"memory\" (default) or \"file:/path/to/queue.db\" for a persistent bbolt-backed frontier")
+	commands.Flags().Bool("resume", false, "Reload the pending frontier and seen-set from --queue before crawling (requires a file: queue)")
+	commands.Flags().String("resume-ttl", "", "Expire --resume frontier/seen-set entries older than this duration (e.g. \"24h\"); empty = never expire")
 	commands.Flags().StringP("user-agent", "u", "web", "User Agent to use\n\tweb: random web user-agent\n\tmobi: random mobile user-agent\n\tor set your own UA string")
 	commands.Flags().StringP("cookie", "", "", "Cookie to use (testA=a; testB=b)")
 	commands.Flags().StringArrayP("header", "H", []string{}, "Header to use (Use multiple -H to set multiple headers)")
@@ -47,21 +56,40 @@ func main() {
 	commands.Flags().IntP("delay", "k", 0, "Fixed delay between requests (seconds)")
 	commands.Flags().IntP("random-delay", "K", 0, "Extra randomized delay (seconds)")
 	commands.Flags().IntP("timeout", "m", 10, "Request timeout (seconds)")
+	commands.Flags().Bool("http2", false, "Enable HTTP/2 on the underlying transport")
+	commands.Flags().Int("max-idle-conns", 0, "Max idle (keep-alive) connections across all hosts; 0 = unlimited")
+	commands.Flags().Int("idle-conn-timeout", 5, "How long an idle connection is kept in the pool before closing (seconds)")
+	commands.Flags().Int("dial-timeout", 60, "Timeout for establishing the TCP connection (seconds)")
+	commands.Flags().Int("keepalive", 30, "TCP keep-alive interval (seconds)")
+	commands.Flags().Int("tls-handshake-timeout", 10, "Timeout for the TLS handshake (seconds)")
 
 	commands.Flags().BoolP("base", "B", false, "Disable sitemap/robots/JS/3rd-party; use only HTML crawling")
 	commands.Flags().BoolP("js", "", true, "Enable linkfinder for javascript files")
 	commands.Flags().BoolP("sitemap", "", false, "Try to crawl sitemap.xml")
+	commands.Flags().Int("sitemap-max-depth", 3, "Max recursion depth into nested <sitemapindex> entries (sitemap.xml, robots.txt Sitemap:, --seed-known)")
+	commands.Flags().String("sitemap-since", "", "Only follow/emit sitemap <url> entries whose <lastmod> is on or after this date (YYYY-MM-DD); entries without a lastmod always pass")
 	commands.Flags().BoolP("robots", "", true, "Try to crawl robots.txt")
-	commands.Flags().BoolP("other-source", "a", false, "Find URLs from 3rd party (Archive.org, CommonCrawl.org, VirusTotal.com, AlienVault.com)")
+	commands.Flags().Bool("ignore-robots", false, "Don't let robots.txt Disallow rules remove URLs from the crawl frontier")
+	commands.Flags().BoolP("other-source", "a", false, "Find URLs from 3rd party (Archive.org, CommonCrawl.org, VirusTotal.com, AlienVault.com, URLScan.io, Chaos, HackerTarget.com)")
 	commands.Flags().BoolP("include-subs", "w", false, "Include subdomains from 3rd party seeders (for --other-source)")
 	commands.Flags().BoolP("include-other-source", "r", false, "Also print other-source URLs (still crawl them)")
+	commands.Flags().String("sources", "", "3rd-party sources to use for --other-source: comma list of wayback,commoncrawl,virustotal,otx,urlscan,chaos,hackertarget; prefix with - to disable one (e.g. \"-virustotal\"); empty = all")
+	commands.Flags().String("config", "", "YAML file of per-source API keys/enable toggles for --other-source (\"sources: {virustotal: {apikey: ..., enabled: true}}\"); defaults to ~/.pathfinder/config.yaml if present")
+	commands.Flags().Int("cc-indices", 3, "Number of most recent CommonCrawl indices to query in parallel (for --other-source)")
 	commands.Flags().BoolP("subs", "", false, "Include subdomains (for full-URL targets only; bare domains auto-enable subs)")
+	commands.Flags().Bool("seed-known", false, "Warm-start the frontier from robots.txt, sitemap.xml (with nested index expansion), and .well-known/ before crawling")
+	commands.Flags().String("scrapers", "all", "Built-in content scrapers to run (emails,jwt,gcp-key,firebase-url,private-key), or \"all\"/\"none\"")
+	commands.Flags().String("scraper-file", "", "YAML/JSON file of additional scraper rules ({name,type,pattern,mime,url}; type is regex, query, or jsonpath)")
+	commands.Flags().String("scope", "primary+related", "Scope policy for discovered links: \"primary\" (only follow in-scope <a href>/JS routes) or \"primary+related\" (also fetch off-scope images/stylesheets/favicons once)")
+	commands.Flags().Int("related-depth", 1, "Whether a TagRelated resource (image, stylesheet, favicon, script file) may still be fetched once from outside the crawl's scope: 0 disables off-scope related fetches, any value >=1 enables it (this is an on/off gate, not a hop count - related assets are always fetched at most once and never recursed into)")
+	commands.Flags().Bool("tag-external", false, "Emit off-scope hyperlinks tagged \"external\" instead of silently dropping them (never fetched, classification only)")
 
 	commands.Flags().BoolP("debug", "", false, "Debug logging")
 	commands.Flags().BoolP("json", "", false, "JSON output")
 	commands.Flags().BoolP("verbose", "v", false, "Verbose logs")
 	commands.Flags().BoolP("quiet", "q", false, "Only print URLs")
 	commands.Flags().BoolP("no-redirect", "", false, "Disallow redirects off-scope")
+	commands.Flags().Bool("tls-fingerprint", false, "Compute a JARM-style TLS fingerprint and cert metadata for each unique host")
 	commands.Flags().BoolP("version", "", false, "Print version")
 	commands.Flags().BoolP("length", "l", false, "Print response lengths")
 	commands.Flags().BoolP("raw", "R", false, "Print raw bodies of visited responses")
@@ -70,12 +98,25 @@ func main() {
 	commands.Flags().Bool("render", false, "Enable selective headless render pass")
 	commands.Flags().Int("render-budget", 6, "Max rendered pages per domain")
 	commands.Flags().Int("render-timeout", 8, "Seconds per rendered page")
+	commands.Flags().Int("render-workers", 4, "Number of concurrent headless tabs sharing the browser pool")
+	commands.Flags().Int("render-per-host", 2, "Max concurrent renders per host (backpressure against a single slow origin)")
+	commands.Flags().Bool("interact", false, "Simulate clicks/typeahead on rendered pages to discover SPA routes and hidden XHR endpoints")
+	commands.Flags().Int("interact-budget", 25, "Max interactive elements to synthetically click per rendered page")
+	commands.Flags().String("screenshot-dir", "", "Write a full-page screenshot of every rendered URL to this directory")
+	commands.Flags().String("screenshot-format", "png", "Screenshot format: png or jpg")
+	commands.Flags().String("screenshot-viewport", "1280x800", "Screenshot viewport as WIDTHxHEIGHT")
+	commands.Flags().String("render-profiles", "desktop", "Comma-separated device profiles to render each page as (mobile,tablet,desktop)")
+	commands.Flags().String("spa-probe", "", "Path to a JS file whose return value (an array of route strings) is merged into discovered SPA routes")
 
 	// output-kind filtering
-	commands.Flags().String("types", "", "Comma-separated allowlist of kinds to emit (href,url,javascript,linkfinder,form,upload-form,robots,sitemap,subdomains,aws,render,network). Empty = all.")
+	commands.Flags().String("types", "", "Comma-separated allowlist of kinds to emit (href,url,javascript,linkfinder,form,upload-form,robots-allow,robots-disallow,robots-sitemap,sitemap,wellknown,subdomains,aws,render,network,interact,ws,sse,sw,manifest,spa-route,github-raw,github-gitconfig,s3-key,youtube-video,tls,softdup,scraper:<name>). Empty = all except softdup, which must be requested explicitly.")
 	commands.Flags().String("exclude-types", "", "Comma-separated denylist of kinds to suppress. Applied after --types if both are set.")
 
 	commands.Flags().SortFlags = false
+}
+
+func main() {
+	registerFlags(commands)
 	if err := commands.Execute(); err != nil {
 		Logger.Error(err)
 		os.Exit(1)
@@ -162,6 +203,28 @@ func run(cmd *cobra.Command, _ []string) {
 		includeOtherSourceResult = false
 	}
 
+	// Resolved once per run (not per target/thread): a bad --sources/--config
+	// name should fail fast rather than surface as a buried per-target log line.
+	var passiveCfg *PassiveConfig
+	var passiveSourcesList []PassiveSource
+	if otherSource {
+		configPath, _ := cmd.Flags().GetString("config")
+		cfg, err := LoadPassiveConfig(configPath)
+		if err != nil {
+			Logger.Errorf("Failed to load --config: %s", err)
+			os.Exit(1)
+		}
+		passiveCfg = cfg
+
+		sourcesSpec, _ := cmd.Flags().GetString("sources")
+		sources, err := selectPassiveSources(sourcesSpec, passiveCfg)
+		if err != nil {
+			Logger.Errorf("Failed to resolve --sources: %s", err)
+			os.Exit(1)
+		}
+		passiveSourcesList = sources
+	}
+
 	// render flags (read in worker and applied per target)
 	var wg sync.WaitGroup
 	inputChan := make(chan string, threads)
@@ -180,10 +243,36 @@ func run(cmd *cobra.Command, _ []string) {
 
 				// Attach headless renderer BEFORE starting the crawl
 				renderEnabled, _ := cmd.Flags().GetBool("render")
-				renderBudget, _ := cmd.Flags().GetInt("render-budget")
-				renderTimeout, _ := cmd.Flags().GetInt("render-timeout")
 				if renderEnabled {
-					StartRenderManager(crawler, renderBudget, time.Duration(renderTimeout)*time.Second)
+					StartRenderManager(crawler, cmd)
+				}
+
+				if seedKnown, _ := cmd.Flags().GetBool("seed-known"); seedKnown {
+					crawler.Prime(siteURL, crawler.C)
+				}
+
+				if resume, _ := cmd.Flags().GetBool("resume"); resume {
+					var resumeTTL time.Duration
+					if ttlStr, _ := cmd.Flags().GetString("resume-ttl"); ttlStr != "" {
+						if d, err := time.ParseDuration(ttlStr); err == nil {
+							resumeTTL = d
+						} else {
+							Logger.Errorf("Failed to parse --resume-ttl %q: %s", ttlStr, err)
+						}
+					}
+					// Restore the seen-set first so the frontier below (and
+					// anything the crawl rediscovers on its own) doesn't
+					// re-fetch URLs this crawl already completed.
+					if seen, err := crawler.Queue.Visited(resumeTTL); err == nil {
+						for _, u := range seen {
+							crawler.urlSet.Duplicate(u)
+						}
+					}
+					if pending, err := crawler.Queue.Pending(resumeTTL); err == nil {
+						for _, item := range pending {
+							_ = crawler.C.Visit(item.URL)
+						}
+					}
 				}
 
 				siteWg.Add(1)
@@ -206,7 +295,14 @@ func run(cmd *cobra.Command, _ []string) {
 					siteWg.Add(1)
 					go func() {
 						defer siteWg.Done()
-						urls := OtherSources(siteURL.Hostname(), includeSubs)
+						ccIndices, _ := cmd.Flags().GetInt("cc-indices")
+						ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+						defer cancel()
+						urls := OtherSources(ctx, siteURL.Hostname(), PassiveOptions{
+							IncludeSubs: includeSubs,
+							CCIndices:   ccIndices,
+							APIKeys:     passiveCfg.apiKeys(),
+						}, passiveSourcesList)
 						for _, u := range urls {
 							u = strings.TrimSpace(u)
 							if u == "" {
@@ -244,6 +340,12 @@ func run(cmd *cobra.Command, _ []string) {
 				if crawler.Output != nil {
 					crawler.Output.Close()
 				}
+				if crawler.Warc != nil {
+					crawler.Warc.Close()
+				}
+				if crawler.Queue != nil {
+					_ = crawler.Queue.Close()
+				}
 			}
 		}()
 	}
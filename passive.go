@@ -0,0 +1,623 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"gopkg.in/yaml.v3"
+)
+
+/* ============================== Passive sources ============================== */
+
+// wurl is one historical URL yielded by a passive source, with the date the
+// source saw it (where the source provides one).
+type wurl struct {
+	date string
+	url  string
+}
+
+// PassiveOptions carries the knobs individual sources care about, so
+// PassiveSource.Fetch doesn't need its own growing parameter list.
+type PassiveOptions struct {
+	IncludeSubs bool
+	CCIndices   int               // how many CommonCrawl indices to fan out across
+	APIKeys     map[string]string // source name -> key, from --config
+}
+
+// PassiveSource is one 3rd-party historical-URL provider (Wayback,
+// CommonCrawl, VirusTotal, AlienVault OTX, URLScan, ...). Fetch runs under
+// ctx so a slow or misbehaving source can be cancelled without blocking the
+// others.
+type PassiveSource interface {
+	Name() string
+	RequiresKey() bool
+	Fetch(ctx context.Context, domain string, opts PassiveOptions) ([]wurl, error)
+}
+
+var passiveSources = map[string]PassiveSource{}
+var passiveSourceOrder []string
+
+func registerPassiveSource(s PassiveSource) {
+	name := s.Name()
+	if _, exists := passiveSources[name]; !exists {
+		passiveSourceOrder = append(passiveSourceOrder, name)
+	}
+	passiveSources[name] = s
+}
+
+func init() {
+	registerPassiveSource(waybackSource{})
+	registerPassiveSource(commonCrawlSource{})
+	registerPassiveSource(virusTotalSource{})
+	registerPassiveSource(otxSource{})
+	registerPassiveSource(urlscanSource{})
+	registerPassiveSource(chaosSource{})
+	registerPassiveSource(hackerTargetSource{})
+}
+
+// selectPassiveSources resolves the --sources flag against the registry.
+// With no bare name in spec it starts from cfg's per-source "enabled"
+// toggles (registered sources default to enabled when cfg has no opinion)
+// and "-name" disables one. Any bare "name" in spec narrows the set to just
+// the names listed, so "wayback,urlscan,-virustotal" selects wayback and
+// urlscan only - entries in --sources always win over --config, since it's
+// the more specific, per-invocation override. An unknown name in --sources,
+// or one given an "enabled" toggle in --config, is a clean error rather than
+// a silently-ignored typo.
+func selectPassiveSources(spec string, cfg *PassiveConfig) ([]PassiveSource, error) {
+	enabled := map[string]bool{}
+	for _, name := range passiveSourceOrder {
+		enabled[name] = true
+	}
+	for name, sc := range cfg.sourceConfigs() {
+		if sc.Enabled == nil {
+			continue
+		}
+		if _, known := passiveSources[name]; !known {
+			return nil, fmt.Errorf("unknown source %q in --config", name)
+		}
+		enabled[name] = *sc.Enabled
+	}
+
+	for _, tok := range strings.Split(spec, ",") {
+		if tok = strings.TrimSpace(tok); tok != "" && !strings.HasPrefix(tok, "-") {
+			for name := range enabled {
+				enabled[name] = false
+			}
+			break
+		}
+	}
+
+	for _, tok := range strings.Split(spec, ",") {
+		tok = strings.TrimSpace(tok)
+		if tok == "" {
+			continue
+		}
+		name, neg := strings.CutPrefix(tok, "-")
+		if _, known := passiveSources[name]; !known {
+			return nil, fmt.Errorf("unknown source %q in --sources", name)
+		}
+		enabled[name] = !neg
+	}
+
+	out := make([]PassiveSource, 0, len(enabled))
+	for _, name := range passiveSourceOrder {
+		if enabled[name] {
+			out = append(out, passiveSources[name])
+		}
+	}
+	return out, nil
+}
+
+/* ============================== --config file ============================== */
+
+// PassiveSourceConfig is one source's entry under the --config file's
+// "sources" map: its API key and an optional enable/disable override.
+// RateLimit is accepted but not yet enforced by any source - it's here so
+// existing config files don't need editing once a source starts reading it.
+type PassiveSourceConfig struct {
+	APIKey    string `yaml:"apikey"`
+	Enabled   *bool  `yaml:"enabled"`
+	RateLimit int    `yaml:"rate_limit"`
+}
+
+// PassiveConfig is the optional --config file: a subfinder-style YAML
+// document keyed by source name, supplying API keys and enable/disable
+// toggles without having to respecify --sources on every invocation.
+type PassiveConfig struct {
+	Sources map[string]PassiveSourceConfig `yaml:"sources"`
+}
+
+func (c *PassiveConfig) sourceConfigs() map[string]PassiveSourceConfig {
+	if c == nil {
+		return nil
+	}
+	return c.Sources
+}
+
+// apiKeys flattens the config into the name->key map PassiveOptions carries.
+func (c *PassiveConfig) apiKeys() map[string]string {
+	out := map[string]string{}
+	for name, sc := range c.sourceConfigs() {
+		if sc.APIKey != "" {
+			out[name] = sc.APIKey
+		}
+	}
+	return out
+}
+
+// defaultPassiveConfigPath is where LoadPassiveConfig looks when --config
+// is empty, mirroring subfinder's per-tool config-file convention.
+func defaultPassiveConfigPath() string {
+	home, err := homedir.Dir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".pathfinder", "config.yaml")
+}
+
+// LoadPassiveConfig reads path as YAML, or - if path is empty - the default
+// location. A missing file at the default location is fine (most users
+// never create one); a missing file at an explicitly-given --config is an
+// error.
+func LoadPassiveConfig(path string) (*PassiveConfig, error) {
+	explicit := path != ""
+	if !explicit {
+		path = defaultPassiveConfigPath()
+	}
+	if path == "" {
+		return &PassiveConfig{}, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !explicit {
+			return &PassiveConfig{}, nil
+		}
+		return nil, err
+	}
+	var cfg PassiveConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// OtherSources runs every source in sources concurrently under ctx and
+// merges their results into a deduped URL list, same shape the caller saw
+// before the registry existed.
+func OtherSources(ctx context.Context, domain string, opts PassiveOptions, sources []PassiveSource) []string {
+	var urls []string
+	out := make(chan wurl, 256)
+	var wg sync.WaitGroup
+
+	for _, src := range sources {
+		wg.Add(1)
+		go func(s PassiveSource) {
+			defer wg.Done()
+			resp, err := s.Fetch(ctx, domain, opts)
+			if err != nil {
+				Logger.Debugf("%s: %v", s.Name(), err)
+				return
+			}
+			for _, r := range resp {
+				if r.url == "" {
+					continue
+				}
+				select {
+				case out <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}(src)
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	for w := range out {
+		urls = append(urls, w.url)
+	}
+	return Unique(urls)
+}
+
+/* ============================== Shared HTTP helper ============================== */
+
+// passiveHTTPClient is shared across passive sources so they get consistent
+// timeouts; each call still layers ctx on top for cancellation.
+var passiveHTTPClient = &http.Client{Timeout: 20 * time.Second}
+
+// passiveGet issues a GET with a couple of retries on transient failures
+// (network errors, 429, 5xx), backing off between attempts and bailing out
+// early if ctx is cancelled.
+func passiveGet(ctx context.Context, reqURL string) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Duration(attempt) * time.Second):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := passiveHTTPClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("%s: status %d", reqURL, resp.StatusCode)
+			resp.Body.Close()
+			continue
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+func passiveGetBody(ctx context.Context, reqURL string) ([]byte, error) {
+	resp, err := passiveGet(ctx, reqURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(resp.Body)
+}
+
+/* ============================== Wayback Machine ============================== */
+
+type waybackSource struct{}
+
+func (waybackSource) Name() string      { return "wayback" }
+func (waybackSource) RequiresKey() bool { return false }
+
+func (waybackSource) Fetch(ctx context.Context, domain string, opts PassiveOptions) ([]wurl, error) {
+	subsWildcard := ""
+	matchType := "host"
+	if opts.IncludeSubs {
+		subsWildcard = "*."
+		matchType = "domain"
+	}
+
+	raw, err := passiveGetBody(ctx, fmt.Sprintf(
+		"https://web.archive.org/cdx/search/cdx?url=%s%s/*&output=json&fl=timestamp,original&collapse=urlkey&matchType=%s",
+		subsWildcard, domain, matchType,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	var wrapper [][]string
+	if err := json.Unmarshal(raw, &wrapper); err != nil {
+		return nil, err
+	}
+
+	out := make([]wurl, 0, len(wrapper))
+	for i, cols := range wrapper {
+		if i == 0 || len(cols) < 2 {
+			// first row is a header when output=json (["timestamp","original"])
+			continue
+		}
+		out = append(out, wurl{date: cols[0], url: cols[1]})
+	}
+	return out, nil
+}
+
+/* ============================== CommonCrawl ============================== */
+
+type commonCrawlSource struct{}
+
+func (commonCrawlSource) Name() string      { return "commoncrawl" }
+func (commonCrawlSource) RequiresKey() bool { return false }
+
+// Fetch fans out over the N most recent CommonCrawl indices in parallel
+// (N from --cc-indices) instead of only ever querying the latest one, since
+// a domain's pages land in whichever crawl happened to catch them.
+func (commonCrawlSource) Fetch(ctx context.Context, domain string, opts PassiveOptions) ([]wurl, error) {
+	subsWildcard := ""
+	if opts.IncludeSubs {
+		subsWildcard = "*."
+	}
+
+	body, err := passiveGetBody(ctx, "https://index.commoncrawl.org/collinfo.json")
+	if err != nil {
+		return nil, fmt.Errorf("fetch index list: %w", err)
+	}
+
+	var indices []struct {
+		ID     string `json:"id"`
+		CDXAPI string `json:"cdx-api"`
+	}
+	if err := json.Unmarshal(body, &indices); err != nil {
+		return nil, fmt.Errorf("parse index list: %w", err)
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no CommonCrawl indices available")
+	}
+
+	n := opts.CCIndices
+	if n <= 0 {
+		n = 1
+	}
+	if n > len(indices) {
+		n = len(indices)
+	}
+
+	results := make([][]wurl, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			idx := indices[i]
+			api := idx.CDXAPI
+			if api == "" {
+				api = fmt.Sprintf("https://index.commoncrawl.org/%s", idx.ID)
+			}
+			queryURL := fmt.Sprintf("%s?url=%s%s/*&output=json", api, subsWildcard, domain)
+			urls, err := fetchCommonCrawlIndex(ctx, queryURL)
+			if err != nil {
+				Logger.Debugf("commoncrawl: index %s: %v", idx.ID, err)
+				return
+			}
+			results[i] = urls
+		}(i)
+	}
+	wg.Wait()
+
+	var out []wurl
+	for _, r := range results {
+		out = append(out, r...)
+	}
+	return out, nil
+}
+
+func fetchCommonCrawlIndex(ctx context.Context, queryURL string) ([]wurl, error) {
+	resp, err := passiveGet(ctx, queryURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	sc := bufio.NewScanner(resp.Body)
+	buf := make([]byte, 1024*1024)
+	sc.Buffer(buf, 10*1024*1024)
+
+	out := make([]wurl, 0)
+	for sc.Scan() {
+		wrapper := struct {
+			URL       string `json:"url"`
+			Timestamp string `json:"timestamp"`
+		}{}
+		if json.Unmarshal(sc.Bytes(), &wrapper) != nil {
+			continue
+		}
+		if wrapper.URL != "" {
+			out = append(out, wurl{date: wrapper.Timestamp, url: wrapper.URL})
+		}
+	}
+	return out, sc.Err()
+}
+
+/* ============================== VirusTotal ============================== */
+
+type virusTotalSource struct{}
+
+func (virusTotalSource) Name() string      { return "virustotal" }
+func (virusTotalSource) RequiresKey() bool { return true }
+
+func (virusTotalSource) Fetch(ctx context.Context, domain string, opts PassiveOptions) ([]wurl, error) {
+	apiKey := opts.APIKeys["virustotal"]
+	if apiKey == "" {
+		apiKey = os.Getenv("VT_API_KEY")
+	}
+	if apiKey == "" {
+		Logger.Warnf("You are not set VirusTotal API Key yet.")
+		return nil, nil
+	}
+
+	body, err := passiveGetBody(ctx, fmt.Sprintf(
+		"https://www.virustotal.com/vtapi/v2/domain/report?apikey=%s&domain=%s", apiKey, domain,
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper := struct {
+		URLs []struct {
+			URL string `json:"url"`
+		} `json:"detected_urls"`
+	}{}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	out := make([]wurl, 0, len(wrapper.URLs))
+	for _, u := range wrapper.URLs {
+		if u.URL != "" {
+			out = append(out, wurl{url: u.URL})
+		}
+	}
+	return out, nil
+}
+
+/* ============================== AlienVault OTX ============================== */
+
+type otxSource struct{}
+
+func (otxSource) Name() string      { return "otx" }
+func (otxSource) RequiresKey() bool { return false }
+
+func (otxSource) Fetch(ctx context.Context, domain string, _ PassiveOptions) ([]wurl, error) {
+	var urls []wurl
+	page := 0
+	maxPages := 10 // safety limit to prevent infinite loops
+
+	for page < maxPages {
+		body, err := passiveGetBody(ctx, fmt.Sprintf(
+			"https://otx.alienvault.com/api/v1/indicators/hostname/%s/url_list?limit=50&page=%d", domain, page,
+		))
+		if err != nil {
+			return urls, err
+		}
+
+		wrapper := struct {
+			HasNext bool `json:"has_next"`
+			URLList []struct {
+				URL string `json:"url"`
+			} `json:"url_list"`
+		}{}
+		if err := json.Unmarshal(body, &wrapper); err != nil {
+			return urls, fmt.Errorf("parse response: %w", err)
+		}
+		for _, u := range wrapper.URLList {
+			if u.URL != "" {
+				urls = append(urls, wurl{url: u.URL})
+			}
+		}
+		if !wrapper.HasNext {
+			break
+		}
+		page++
+	}
+	return urls, nil
+}
+
+/* ============================== URLScan.io ============================== */
+
+type urlscanSource struct{}
+
+func (urlscanSource) Name() string      { return "urlscan" }
+func (urlscanSource) RequiresKey() bool { return false }
+
+func (urlscanSource) Fetch(ctx context.Context, domain string, _ PassiveOptions) ([]wurl, error) {
+	body, err := passiveGetBody(ctx, fmt.Sprintf(
+		"https://urlscan.io/api/v1/search/?q=domain:%s", url.QueryEscape(domain),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper := struct {
+		Results []struct {
+			Page struct {
+				URL string `json:"url"`
+			} `json:"page"`
+			Task struct {
+				Time string `json:"time"`
+			} `json:"task"`
+		} `json:"results"`
+	}{}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	out := make([]wurl, 0, len(wrapper.Results))
+	for _, r := range wrapper.Results {
+		if r.Page.URL != "" {
+			out = append(out, wurl{date: r.Task.Time, url: r.Page.URL})
+		}
+	}
+	return out, nil
+}
+
+/* ============================== ProjectDiscovery Chaos ============================== */
+
+type chaosSource struct{}
+
+func (chaosSource) Name() string      { return "chaos" }
+func (chaosSource) RequiresKey() bool { return true }
+
+func (chaosSource) Fetch(ctx context.Context, domain string, opts PassiveOptions) ([]wurl, error) {
+	apiKey := opts.APIKeys["chaos"]
+	if apiKey == "" {
+		apiKey = os.Getenv("CHAOS_API_KEY")
+	}
+	if apiKey == "" {
+		Logger.Warnf("You are not set Chaos API Key yet.")
+		return nil, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("https://dns.projectdiscovery.io/dns/%s/subdomains", domain), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", apiKey)
+	resp, err := passiveHTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	wrapper := struct {
+		Domain     string   `json:"domain"`
+		Subdomains []string `json:"subdomains"`
+	}{}
+	if err := json.Unmarshal(body, &wrapper); err != nil {
+		return nil, fmt.Errorf("parse response: %w", err)
+	}
+
+	out := make([]wurl, 0, len(wrapper.Subdomains))
+	for _, sub := range wrapper.Subdomains {
+		if sub == "" {
+			continue
+		}
+		out = append(out, wurl{url: fmt.Sprintf("https://%s.%s", sub, wrapper.Domain)})
+	}
+	return out, nil
+}
+
+/* ============================== HackerTarget ============================== */
+
+type hackerTargetSource struct{}
+
+func (hackerTargetSource) Name() string      { return "hackertarget" }
+func (hackerTargetSource) RequiresKey() bool { return false }
+
+// Fetch hits HackerTarget's free (rate-limited) hostsearch API, which
+// returns plain "host,ip" lines rather than JSON.
+func (hackerTargetSource) Fetch(ctx context.Context, domain string, _ PassiveOptions) ([]wurl, error) {
+	body, err := passiveGetBody(ctx, fmt.Sprintf(
+		"https://api.hackertarget.com/hostsearch/?q=%s", url.QueryEscape(domain),
+	))
+	if err != nil {
+		return nil, err
+	}
+	text := string(body)
+	if strings.Contains(text, "API count exceeded") {
+		return nil, fmt.Errorf("hackertarget: rate limited")
+	}
+
+	var out []wurl
+	for _, line := range strings.Split(text, "\n") {
+		host := strings.TrimSpace(strings.SplitN(line, ",", 2)[0])
+		if host == "" {
+			continue
+		}
+		out = append(out, wurl{url: "https://" + host})
+	}
+	return out, nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSelectPassiveSourcesDefaultsToAll(t *testing.T) {
+	sources, err := selectPassiveSources("", nil)
+	if err != nil {
+		t.Fatalf("selectPassiveSources: %v", err)
+	}
+	if len(sources) != len(passiveSourceOrder) {
+		t.Errorf("got %d sources, want %d (all registered)", len(sources), len(passiveSourceOrder))
+	}
+}
+
+func TestSelectPassiveSourcesNegation(t *testing.T) {
+	sources, err := selectPassiveSources("-virustotal", nil)
+	if err != nil {
+		t.Fatalf("selectPassiveSources: %v", err)
+	}
+	for _, s := range sources {
+		if s.Name() == "virustotal" {
+			t.Errorf("virustotal should be disabled by -virustotal, got %v", names(sources))
+		}
+	}
+	if len(sources) != len(passiveSourceOrder)-1 {
+		t.Errorf("got %d sources, want %d", len(sources), len(passiveSourceOrder)-1)
+	}
+}
+
+func TestSelectPassiveSourcesConfigDisableOverriddenBySpec(t *testing.T) {
+	disabled := false
+	cfg := &PassiveConfig{Sources: map[string]PassiveSourceConfig{
+		"wayback": {Enabled: &disabled},
+	}}
+
+	sources, err := selectPassiveSources("", cfg)
+	if err != nil {
+		t.Fatalf("selectPassiveSources: %v", err)
+	}
+	for _, s := range sources {
+		if s.Name() == "wayback" {
+			t.Errorf("wayback should be disabled by --config, got %v", names(sources))
+		}
+	}
+
+	sources, err = selectPassiveSources("wayback", cfg)
+	if err != nil {
+		t.Fatalf("selectPassiveSources: %v", err)
+	}
+	found := false
+	for _, s := range sources {
+		if s.Name() == "wayback" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("--sources=wayback should win over --config disable, got %v", names(sources))
+	}
+}
+
+func TestSelectPassiveSourcesUnknownName(t *testing.T) {
+	if _, err := selectPassiveSources("not-a-real-source", nil); err == nil {
+		t.Error("expected error for unknown --sources name, got nil")
+	}
+
+	cfg := &PassiveConfig{Sources: map[string]PassiveSourceConfig{
+		"not-a-real-source": {},
+	}}
+	enabled := true
+	cfg.Sources["not-a-real-source"] = PassiveSourceConfig{Enabled: &enabled}
+	if _, err := selectPassiveSources("", cfg); err == nil {
+		t.Error("expected error for unknown --config source name, got nil")
+	}
+}
+
+func names(sources []PassiveSource) []string {
+	out := make([]string, len(sources))
+	for i, s := range sources {
+		out[i] = s.Name()
+	}
+	return out
+}
+
+func TestLoadPassiveConfigParsesAPIKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	contents := "sources:\n  virustotal:\n    apikey: abc123\n  chaos:\n    apikey: def456\n    enabled: false\n"
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+
+	cfg, err := LoadPassiveConfig(path)
+	if err != nil {
+		t.Fatalf("LoadPassiveConfig: %v", err)
+	}
+	keys := cfg.apiKeys()
+	if keys["virustotal"] != "abc123" {
+		t.Errorf("virustotal key = %q, want abc123", keys["virustotal"])
+	}
+	if keys["chaos"] != "def456" {
+		t.Errorf("chaos key = %q, want def456", keys["chaos"])
+	}
+	if cfg.Sources["chaos"].Enabled == nil || *cfg.Sources["chaos"].Enabled {
+		t.Error("chaos.enabled = true or nil, want false")
+	}
+}
+
+func TestLoadPassiveConfigMissingExplicitPathIsError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "does-not-exist.yaml")
+	if _, err := LoadPassiveConfig(path); err == nil {
+		t.Error("expected error for missing explicit --config path, got nil")
+	}
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	jsoniter "github.com/json-iterator/go"
+
+	"github.com/gocolly/colly/v2"
+)
+
+/* ============================== Seeding pass (robots/sitemap/.well-known) ============================== */
+
+var sitemapDirectiveRE = regexp.MustCompile(`(?i)^\s*sitemap:\s*(\S+)`)
+
+// wellKnownPaths are fetched once up front when --seed-known is set, ahead
+// of the normal crawl, to warm-start the frontier with whatever the site
+// publishes about itself.
+var wellKnownPaths = []string{
+	"/.well-known/security.txt",
+	"/.well-known/assetlinks.json",
+}
+
+// Prime runs the --seed-known warm-start pass: robots.txt (for its
+// Sitemap: directives), sitemap.xml, and the standard .well-known probes.
+// It must run before crawler.C.Visit(site) so anything it discovers is
+// already queued by the time the main crawl starts.
+func (crawler *Crawler) Prime(site *url.URL, c *colly.Collector) {
+	robotsURL := site.String() + "/robots.txt"
+	body, status, err := fetchOnce(c, robotsURL)
+	if err == nil && status == 200 && len(body) > 0 {
+		for _, line := range strings.Split(string(body), "\n") {
+			if m := sitemapDirectiveRE.FindStringSubmatch(line); m != nil {
+				crawler.primeSitemap(strings.TrimSpace(m[1]), c, 0)
+			}
+		}
+	}
+
+	// Always also try the conventional location even if robots.txt didn't
+	// advertise one.
+	crawler.primeSitemap(site.String()+"/sitemap.xml", c, 0)
+
+	for _, p := range wellKnownPaths {
+		crawler.primeWellKnown(site.String()+p, c)
+	}
+}
+
+// sitemapFanout bounds how many nested sitemaps (from one <sitemapindex>)
+// are fetched concurrently, so a large index doesn't open hundreds of
+// connections to one host at once.
+const sitemapFanout = 4
+
+// SitemapEntry is one parsed <url> entry from a sitemap, carrying the
+// lastmod/changefreq metadata alongside the bare location so JSON output
+// can surface it.
+type SitemapEntry struct {
+	Loc        string
+	LastMod    string
+	ChangeFreq string
+}
+
+// emitSitemapEntry dedupes and prints a discovered sitemap URL, including
+// LastMod/ChangeFreq in JSON mode the same way emitURL attaches status/length.
+func (crawler *Crawler) emitSitemapEntry(e SitemapEntry) {
+	if crawler.urlSet.Duplicate(e.Loc) {
+		return
+	}
+	out := fmt.Sprintf("[sitemap] - %s", e.Loc)
+	if crawler.JsonOutput {
+		sout := SpiderOutput{Input: crawler.Input, Source: "sitemap", OutputType: "sitemap", Output: e.Loc}
+		data, err := jsoniter.Marshal(struct {
+			SpiderOutput
+			LastMod    string `json:"lastmod,omitempty"`
+			ChangeFreq string `json:"changefreq,omitempty"`
+		}{sout, e.LastMod, e.ChangeFreq})
+		if err == nil {
+			out = string(data)
+		}
+	} else if crawler.Quiet {
+		out = e.Loc
+	}
+	crawler.emitLine("sitemap", out)
+}
+
+// sitemapAfterSince reports whether entry lastmod (if parseable) is on or
+// after crawler.sitemapSince. Entries with no/unparseable lastmod always
+// pass, since --sitemap-since is a "skip known-stale pages" filter, not a
+// strict schema requirement.
+func (crawler *Crawler) sitemapAfterSince(lastMod string) bool {
+	if crawler.sitemapSince.IsZero() || lastMod == "" {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, lastMod)
+	if err != nil {
+		t, err = time.Parse("2006-01-02", lastMod)
+	}
+	if err != nil {
+		return true
+	}
+	return !t.Before(crawler.sitemapSince)
+}
+
+// primeSitemap fetches a (possibly gzipped) sitemap URL and recurses into
+// nested <sitemapindex> entries up to crawler.sitemapMaxDepth levels deep,
+// skipping any URL it has already fetched (sitemapSeen) to guard against
+// cycles between sitemaps that reference each other.
+func (crawler *Crawler) primeSitemap(sitemapURL string, c *colly.Collector, depth int) {
+	if depth > crawler.sitemapMaxDepth || crawler.sitemapSeen.Duplicate(sitemapURL) {
+		return
+	}
+	body, status, contentType, err := fetchOnceCT(c, sitemapURL)
+	if err != nil || status != 200 || len(body) == 0 {
+		return
+	}
+	lowerURL := strings.ToLower(sitemapURL)
+	lowerCT := strings.ToLower(contentType)
+	if strings.HasSuffix(lowerURL, ".gz") || strings.Contains(lowerCT, "gzip") {
+		if gr, gerr := gzip.NewReader(bytes.NewReader(body)); gerr == nil {
+			if ungz, rerr := io.ReadAll(gr); rerr == nil {
+				body = ungz
+			}
+			_ = gr.Close()
+		}
+	}
+
+	var us urlset
+	if xml.Unmarshal(body, &us) == nil && len(us.URLs) > 0 {
+		for _, e := range us.URLs {
+			loc := strings.TrimSpace(e.Loc)
+			if loc == "" || !crawler.sitemapAfterSince(e.LastMod) {
+				continue
+			}
+			crawler.emitSitemapEntry(SitemapEntry{Loc: loc, LastMod: e.LastMod, ChangeFreq: e.ChangeFreq})
+			_ = c.Visit(loc)
+		}
+		return
+	}
+
+	var si sitemapIndex
+	if xml.Unmarshal(body, &si) == nil && len(si.Maps) > 0 {
+		sem := make(chan struct{}, sitemapFanout)
+		var wg sync.WaitGroup
+		for _, e := range si.Maps {
+			loc := strings.TrimSpace(e.Loc)
+			if loc == "" {
+				continue
+			}
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(loc string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				crawler.primeSitemap(loc, c, depth+1)
+			}(loc)
+		}
+		wg.Wait()
+	}
+}
+
+var urlLikeRE = regexp.MustCompile(`https?://[^\s"'<>]+`)
+
+// primeWellKnown fetches a .well-known resource and pulls out anything that
+// looks like a URL (security.txt is free-form text; assetlinks.json is
+// JSON but we don't need a strict schema to harvest URLs out of it).
+func (crawler *Crawler) primeWellKnown(u string, c *colly.Collector) {
+	body, status, err := fetchOnce(c, u)
+	if err != nil || status != 200 || len(body) == 0 {
+		return
+	}
+	crawler.emitSeedURL("wellknown", u)
+	_ = c.Visit(u)
+
+	if strings.HasSuffix(u, ".json") {
+		var generic interface{}
+		if json.Unmarshal(body, &generic) != nil {
+			return // malformed JSON; don't go scraping its text as URLs
+		}
+	}
+	for _, found := range urlLikeRE.FindAllString(string(body), -1) {
+		crawler.emitSeedURL("wellknown", found)
+		_ = c.Visit(found)
+	}
+}
+
+func (crawler *Crawler) emitSeedURL(kind, u string) {
+	if crawler.urlSet.Duplicate(u) {
+		return
+	}
+	crawler.emitLine(kind, fmt.Sprintf("[%s] - %s", kind, u))
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+/* ============================== Visit queue ============================== */
+
+// QueueItem is one pending-or-in-flight frontier entry.
+type QueueItem struct {
+	URL         string `json:"url"`
+	Depth       int    `json:"depth"`
+	Referer     string `json:"referer"`
+	CollectorID string `json:"collector_id"`
+	Timestamp   int64  `json:"ts"`
+}
+
+// VisitQueue tracks the crawl frontier independently of colly's in-memory
+// async queue, so a killed run can be resumed without re-fetching URLs it
+// already queued. Items are pushed when a request is issued and removed
+// once that request resolves (response or error); whatever remains in the
+// queue at exit is the unfinished frontier.
+//
+// MarkVisited separately records URLs whose response reached colly's
+// OnResponse (a status below 203; error statuses such as 4xx and transport
+// errors only remove the URL from the frontier), so --resume can restore
+// the seen-set and skip re-fetching them entirely rather than just
+// resuming the still-pending frontier. Pending and Visited both take a
+// ttl (--resume-ttl); entries older than ttl are treated as stale and
+// excluded, 0 meaning no expiry.
+type VisitQueue interface {
+	Push(item QueueItem) error
+	Remove(url string) error
+	Pending(ttl time.Duration) ([]QueueItem, error)
+	MarkVisited(url string) error
+	Visited(ttl time.Duration) ([]string, error)
+	Close() error
+}
+
+/* ----- In-memory default ----- */
+
+// MemoryQueue is the zero-config default: it bounds nothing and doesn't
+// survive a restart, matching colly's existing behavior.
+type MemoryQueue struct {
+	mu      sync.Mutex
+	items   map[string]QueueItem
+	visited map[string]int64
+}
+
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{items: map[string]QueueItem{}, visited: map[string]int64{}}
+}
+
+func (q *MemoryQueue) Push(item QueueItem) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	item.Timestamp = time.Now().Unix()
+	q.items[item.URL] = item
+	return nil
+}
+
+func (q *MemoryQueue) Remove(url string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.items, url)
+	return nil
+}
+
+func (q *MemoryQueue) Pending(ttl time.Duration) ([]QueueItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cutoff := ttlCutoff(ttl)
+	out := make([]QueueItem, 0, len(q.items))
+	for _, it := range q.items {
+		if it.Timestamp < cutoff {
+			continue
+		}
+		out = append(out, it)
+	}
+	return out, nil
+}
+
+func (q *MemoryQueue) MarkVisited(url string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.visited[url] = time.Now().Unix()
+	return nil
+}
+
+func (q *MemoryQueue) Visited(ttl time.Duration) ([]string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	cutoff := ttlCutoff(ttl)
+	out := make([]string, 0, len(q.visited))
+	for u, ts := range q.visited {
+		if ts < cutoff {
+			continue
+		}
+		out = append(out, u)
+	}
+	return out, nil
+}
+
+func (q *MemoryQueue) Close() error { return nil }
+
+// ttlCutoff returns the Unix timestamp before which an entry is considered
+// stale, or 0 (meaning "no entry is too old") when ttl is non-positive.
+func ttlCutoff(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return time.Now().Add(-ttl).Unix()
+}
+
+/* ----- BoltDB-backed persistent queue ----- */
+
+var (
+	boltQueueBucket   = []byte("frontier")
+	boltVisitedBucket = []byte("visited")
+)
+
+// BoltQueue persists the frontier (and, separately, the seen-set) to a
+// bbolt file so million-URL crawls don't have to hold every pending item
+// in RAM, and so `--resume` can pick a killed crawl back up without
+// re-fetching what it already finished.
+type BoltQueue struct {
+	db *bolt.DB
+}
+
+// NewBoltQueue opens (creating if needed) the bbolt file at path.
+func NewBoltQueue(path string) (*BoltQueue, error) {
+	db, err := bolt.Open(path, 0o600, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(boltQueueBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(boltVisitedBucket)
+		return err
+	}); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	return &BoltQueue{db: db}, nil
+}
+
+func (q *BoltQueue) Push(item QueueItem) error {
+	item.Timestamp = time.Now().Unix()
+	data, err := json.Marshal(item)
+	if err != nil {
+		return err
+	}
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltQueueBucket).Put([]byte(item.URL), data)
+	})
+}
+
+func (q *BoltQueue) Remove(url string) error {
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltQueueBucket).Delete([]byte(url))
+	})
+}
+
+func (q *BoltQueue) Pending(ttl time.Duration) ([]QueueItem, error) {
+	cutoff := ttlCutoff(ttl)
+	var out []QueueItem
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltQueueBucket).ForEach(func(k, v []byte) error {
+			var item QueueItem
+			if err := json.Unmarshal(v, &item); err != nil {
+				return nil
+			}
+			if item.Timestamp < cutoff {
+				return nil
+			}
+			out = append(out, item)
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (q *BoltQueue) MarkVisited(url string) error {
+	data, err := json.Marshal(time.Now().Unix())
+	if err != nil {
+		return err
+	}
+	return q.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltVisitedBucket).Put([]byte(url), data)
+	})
+}
+
+func (q *BoltQueue) Visited(ttl time.Duration) ([]string, error) {
+	cutoff := ttlCutoff(ttl)
+	var out []string
+	err := q.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltVisitedBucket).ForEach(func(k, v []byte) error {
+			var ts int64
+			if err := json.Unmarshal(v, &ts); err != nil {
+				return nil
+			}
+			if ts < cutoff {
+				return nil
+			}
+			out = append(out, string(k))
+			return nil
+		})
+	})
+	return out, err
+}
+
+func (q *BoltQueue) Close() error {
+	return q.db.Close()
+}
+
+/* ----- Construction from the --queue flag ----- */
+
+// NewVisitQueue builds a VisitQueue from the --queue flag value. An empty
+// spec or "memory" gives the in-memory default; "file:/path/to/queue.db"
+// opens (or creates) a persistent bbolt-backed queue at that path.
+func NewVisitQueue(spec string) (VisitQueue, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "memory" {
+		return NewMemoryQueue(), nil
+	}
+	if path, ok := strings.CutPrefix(spec, "file:"); ok {
+		return NewBoltQueue(path)
+	}
+	return NewMemoryQueue(), nil
+}
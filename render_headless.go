@@ -1,136 +1,677 @@
-//go:build headless
-
-package main
-
-import (
-	"context"
-	"net/url"
-	"regexp"
-	"strings"
-	"time"
-
-	"github.com/chromedp/cdproto/fetch"
-	"github.com/chromedp/cdproto/network"
-	"github.com/chromedp/chromedp"
-	"github.com/gocolly/colly/v2"
-)
-
-// StartRenderManager spins up a headless Chrome and selectively renders pages.
-// Heuristics: small HTML responses likely to be SPAs, plus the start URL.
-// We block heavy assets (images/css/media/fonts) to keep rendering light.
-func StartRenderManager(c *Crawler, budget int, perPage time.Duration) {
-	if budget <= 0 {
-		budget = 6
-	}
-	if perPage <= 0 {
-		perPage = 8 * time.Second
-	}
-
-	// queue of pages to render
-	queue := make(chan string, 64)
-	seenRender := NewStringFilter()
-
-	// Seed with the start URL
-	queue <- c.site.String()
-
-	// Heuristic: enqueue HTML pages that look small (common for SPA shells)
-	c.C.OnResponse(func(r *colly.Response) {
-		if budget <= 0 {
-			return
-		}
-		ct := strings.ToLower(r.Headers.Get("Content-Type"))
-		if strings.Contains(ct, "text/html") && len(r.Body) < 60*1024 {
-			select {
-			case queue <- r.Request.URL.String():
-			default:
-				// queue full -> skip
-			}
-		}
-	})
-
-	go func() {
-		// Build a browser context
-		ctx, cancel := chromedp.NewContext(context.Background())
-		defer cancel()
-
-		// Enable Network & Fetch interception (to block heavy resources)
-		if err := chromedp.Run(ctx,
-			network.Enable(),
-			fetch.Enable().WithPatterns([]*fetch.RequestPattern{
-				{URLPattern: "*"}, // intercept everything; weâ€™ll filter by resource type
-			}),
-		); err != nil {
-			// If Chrome fails to start, just bail out silently
-			return
-		}
-
-		// Listen for requests: block heavy types; forward XHR/Fetch URLs back to crawler
-		chCtx, chCancel := context.WithCancel(ctx)
-		defer chCancel()
-
-		chromedp.ListenTarget(chCtx, func(ev interface{}) {
-			switch e := ev.(type) {
-			case *fetch.EventRequestPaused:
-				// Block heavy resource types to keep the render lean
-				switch e.ResourceType {
-				case network.ResourceTypeImage,
-					network.ResourceTypeStylesheet,
-					network.ResourceTypeMedia,
-					network.ResourceTypeFont:
-					_ = fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient).Do(chCtx)
-				default:
-					_ = fetch.ContinueRequest(e.RequestID).Do(chCtx)
-				}
-
-			case *network.EventRequestWillBeSent:
-				// Capture in-scope XHR/Fetch URLs (JS-driven endpoints)
-				if e.Type == network.ResourceTypeXHR || e.Type == network.ResourceTypeFetch {
-					reqURL := e.Request.URL
-					if inScopeStr(reqURL, c.C.URLFilters) && !c.urlSet.Duplicate(reqURL) {
-						// Feed back to the crawler
-						_ = c.C.Visit(reqURL)
-						// Emit via filter so --types works
-						c.emitLine("network", "[network] - "+reqURL)
-					}
-				}
-			}
-		})
-
-		for budget > 0 {
-			select {
-			case u := <-queue:
-				if u == "" || seenRender.Duplicate(u) {
-					continue
-				}
-				if !inScopeStr(u, c.C.URLFilters) {
-					continue
-				}
-
-				// Emit a render marker (respects --types/--exclude-types)
-				c.emitLine("render", "[render] - "+u)
-
-				// Navigate and give the page a short window to load & fire its XHR
-				pageCtx, cancelPage := context.WithTimeout(ctx, perPage)
-				_ = chromedp.Run(pageCtx,
-					chromedp.Navigate(u),
-					chromedp.WaitReady("body", chromedp.ByQuery),
-					chromedp.Sleep(1500*time.Millisecond), // simple "network idle" window
-				)
-				cancelPage()
-				budget--
-
-			case <-time.After(3 * time.Second):
-				// idle tick; loop again until budget exhausted
-			}
-		}
-	}()
-}
-
-// Helpers
-func inScopeStr(raw string, filters []*regexp.Regexp) bool {
-	u, err := url.Parse(raw)
-	if err != nil {
-		return false
-	}
-	return InScope(u, filters)
-}
+//go:build headless
+
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/chromedp/cdproto/emulation"
+	"github.com/chromedp/cdproto/fetch"
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/cdproto/page"
+	"github.com/chromedp/chromedp"
+	"github.com/gocolly/colly/v2"
+	"github.com/spf13/cobra"
+)
+
+// RenderTask describes one page to be rendered by the SsrPool.
+type RenderTask struct {
+	URL     string
+	Timeout time.Duration
+}
+
+// RenderProfile describes one device/viewport combination the render pool
+// should emulate. Mobile and desktop variants of the same page frequently
+// expose different endpoints (m. prefixes, AMP variants, different bundle
+// splits), so each profile is rendered as its own pass over the page.
+type RenderProfile struct {
+	Name              string
+	UserAgent         string
+	Width             int64
+	Height            int64
+	DeviceScaleFactor float64
+	Mobile            bool
+	TouchEnabled      bool
+}
+
+var builtinRenderProfiles = map[string]RenderProfile{
+	"mobile": {
+		Name:              "mobile",
+		UserAgent:         "Mozilla/5.0 (iPhone; CPU iPhone OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1",
+		Width:             390,
+		Height:            844,
+		DeviceScaleFactor: 3,
+		Mobile:            true,
+		TouchEnabled:      true,
+	},
+	"tablet": {
+		Name:              "tablet",
+		UserAgent:         "Mozilla/5.0 (iPad; CPU OS 15_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/15.0 Mobile/15E148 Safari/604.1",
+		Width:             820,
+		Height:            1180,
+		DeviceScaleFactor: 2,
+		Mobile:            true,
+		TouchEnabled:      true,
+	},
+	"desktop": {
+		Name:              "desktop",
+		UserAgent:         "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/100.0.0.0 Safari/537.36",
+		Width:             1920,
+		Height:            1080,
+		DeviceScaleFactor: 1,
+		Mobile:            false,
+		TouchEnabled:      false,
+	},
+}
+
+// parseRenderProfiles turns a "--render-profiles mobile,desktop" CSV into
+// the matching built-in profiles, skipping unknown names.
+func parseRenderProfiles(csv string) []RenderProfile {
+	if strings.TrimSpace(csv) == "" {
+		return []RenderProfile{builtinRenderProfiles["desktop"]}
+	}
+	var profiles []RenderProfile
+	for _, name := range strings.Split(csv, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if p, ok := builtinRenderProfiles[name]; ok {
+			profiles = append(profiles, p)
+		}
+	}
+	if len(profiles) == 0 {
+		profiles = []RenderProfile{builtinRenderProfiles["desktop"]}
+	}
+	return profiles
+}
+
+// SsrPool owns a fixed set of chromedp tabs (one per worker) backed by a
+// single shared ExecAllocator, and fans rendering work out across them.
+// TaskChannel is the raw intake queue (seed URLs + heuristic discoveries);
+// the dispatcher goroutine dedupes against seenRender and in-scope filters
+// before publishing onto JobsChannel, which the workers consume from.
+type SsrPool struct {
+	c       *Crawler
+	workers int
+	perHost int
+
+	interact       bool
+	interactBudget int
+
+	screenshotDir      string
+	screenshotFormat   string
+	screenshotViewport [2]int64
+
+	profiles []RenderProfile
+
+	extraProbeJS string
+
+	allocCtx    context.Context
+	allocCancel context.CancelFunc
+
+	TaskChannel chan *RenderTask
+	JobsChannel chan *RenderTask
+
+	seenRender    *StringFilter
+	renderedUnits int64
+
+	// closeMu guards both TaskChannel's close (by the teardown goroutine)
+	// and every send to it (via tryEnqueue), so a late OnResponse callback
+	// can never observe "not yet closed" and then send after the teardown
+	// goroutine closes it underneath it - the two can't interleave since
+	// they hold the same lock.
+	closeMu sync.Mutex
+	closed  bool
+
+	hostSemMu sync.Mutex
+	hostSem   map[string]chan struct{}
+}
+
+// tryEnqueue sends task on TaskChannel unless the pool has already been
+// torn down. Locking closeMu for the whole check-then-send keeps this from
+// racing the teardown goroutine's close(TaskChannel), which takes the same
+// lock before closing.
+func (pool *SsrPool) tryEnqueue(task *RenderTask) {
+	pool.closeMu.Lock()
+	defer pool.closeMu.Unlock()
+	if pool.closed {
+		return
+	}
+	select {
+	case pool.TaskChannel <- task:
+	default:
+		// queue full -> skip
+	}
+}
+
+// NewSsrPool builds a pool of `workers` tabs sharing one browser process.
+// perHost bounds how many of those tabs may be rendering the same host at
+// once, so a single slow/misbehaving origin can't starve the others.
+func NewSsrPool(c *Crawler, workers, perHost int) *SsrPool {
+	if workers <= 0 {
+		workers = 4
+	}
+	if perHost <= 0 {
+		perHost = 2
+	}
+
+	allocCtx, allocCancel := chromedp.NewExecAllocator(context.Background())
+
+	return &SsrPool{
+		c:           c,
+		workers:     workers,
+		perHost:     perHost,
+		allocCtx:    allocCtx,
+		allocCancel: allocCancel,
+		TaskChannel: make(chan *RenderTask, 256),
+		JobsChannel: make(chan *RenderTask, 256),
+		seenRender:  NewStringFilter(),
+		hostSem:     map[string]chan struct{}{},
+		profiles:    []RenderProfile{builtinRenderProfiles["desktop"]},
+	}
+}
+
+// hostGate returns the per-host semaphore for u's host, creating it lazily.
+func (p *SsrPool) hostGate(host string) chan struct{} {
+	p.hostSemMu.Lock()
+	defer p.hostSemMu.Unlock()
+	sem, ok := p.hostSem[host]
+	if !ok {
+		sem = make(chan struct{}, p.perHost)
+		p.hostSem[host] = sem
+	}
+	return sem
+}
+
+// dispatch pulls from TaskChannel, filters duplicates/out-of-scope URLs, and
+// republishes onto JobsChannel for the worker pool to pick up.
+func (p *SsrPool) dispatch(done <-chan struct{}) {
+	for {
+		select {
+		case task, ok := <-p.TaskChannel:
+			if !ok {
+				close(p.JobsChannel)
+				return
+			}
+			if task == nil || task.URL == "" || p.seenRender.Duplicate(task.URL) {
+				continue
+			}
+			if !inScopeStr(task.URL, p.c.C.URLFilters) {
+				continue
+			}
+			select {
+			case p.JobsChannel <- task:
+			case <-done:
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// worker drives one tab: navigate, wait for body, let XHR settle, release.
+func (p *SsrPool) worker(id int) {
+	ctx, cancel := chromedp.NewContext(p.allocCtx)
+	defer cancel()
+
+	if err := chromedp.Run(ctx,
+		network.Enable(),
+		fetch.Enable().WithPatterns([]*fetch.RequestPattern{{URLPattern: "*"}}),
+	); err != nil {
+		return
+	}
+
+	chCtx, chCancel := context.WithCancel(ctx)
+	defer chCancel()
+
+	chromedp.ListenTarget(chCtx, func(ev interface{}) {
+		switch e := ev.(type) {
+		case *fetch.EventRequestPaused:
+			switch e.ResourceType {
+			case network.ResourceTypeImage,
+				network.ResourceTypeStylesheet,
+				network.ResourceTypeMedia,
+				network.ResourceTypeFont:
+				_ = fetch.FailRequest(e.RequestID, network.ErrorReasonBlockedByClient).Do(chCtx)
+			default:
+				_ = fetch.ContinueRequest(e.RequestID).Do(chCtx)
+			}
+
+		case *network.EventRequestWillBeSent:
+			switch e.Type {
+			case network.ResourceTypeXHR, network.ResourceTypeFetch:
+				p.feedDiscoveredURL(e.Request.URL, "network", "[network] - ")
+			case network.ResourceTypeManifest:
+				p.feedDiscoveredURL(e.Request.URL, "manifest", "[manifest] - ")
+			case network.ResourceTypeEventSource:
+				p.feedDiscoveredURL(e.Request.URL, "sse", "[sse] - ")
+			case network.ResourceTypeOther:
+				if e.Initiator != nil && e.Initiator.Type == network.InitiatorTypeOther {
+					p.feedDiscoveredURL(e.Request.URL, "sw", "[sw] - ")
+				}
+			}
+
+		case *network.EventWebSocketCreated:
+			p.feedDiscoveredURL(e.URL, "ws", "[ws] - ")
+
+		case *network.EventEventSourceMessageReceived:
+			// The SSE stream's own URL isn't on this event; the initiating
+			// request is captured separately via EventRequestWillBeSent, so
+			// here we just mark that a live SSE channel is in use.
+			p.c.emitLine("sse", fmt.Sprintf("[sse] - event=%s", e.EventName))
+		}
+	})
+
+	for task := range p.JobsChannel {
+		u, err := url.Parse(task.URL)
+		if err != nil {
+			continue
+		}
+		gate := p.hostGate(u.Hostname())
+		gate <- struct{}{}
+
+		timeout := task.Timeout
+		if timeout <= 0 {
+			timeout = 8 * time.Second
+		}
+
+		for _, profile := range p.profiles {
+			p.c.emitLine("render", fmt.Sprintf("[render] - [%s] - %s", profile.Name, task.URL))
+
+			pageCtx, cancelPage := context.WithTimeout(ctx, timeout)
+			_ = chromedp.Run(pageCtx,
+				emulation.SetUserAgentOverride(profile.UserAgent),
+				emulation.SetDeviceMetricsOverride(profile.Width, profile.Height, profile.DeviceScaleFactor, profile.Mobile).
+					WithScreenOrientation(&emulation.ScreenOrientation{Type: emulation.OrientationTypePortraitPrimary, Angle: 0}),
+				chromedp.Navigate(task.URL),
+				chromedp.WaitReady("body", chromedp.ByQuery),
+			)
+			p.runSpaProbe(pageCtx, task.URL)
+			if p.interact {
+				p.runInteract(pageCtx, task.URL)
+			}
+			_ = chromedp.Run(pageCtx, chromedp.Sleep(1500*time.Millisecond))
+			if p.screenshotDir != "" {
+				p.captureScreenshot(pageCtx, task.URL)
+			}
+			cancelPage()
+			atomic.AddInt64(&p.renderedUnits, 1)
+		}
+
+		<-gate
+	}
+}
+
+// captureScreenshot takes a full-page screenshot of the currently-loaded
+// page and writes it under screenshotDir, keyed by sha1(url) so repeated
+// renders of the same page overwrite rather than pile up.
+func (p *SsrPool) captureScreenshot(ctx context.Context, pageURL string) {
+	format := page.CaptureScreenshotFormatPng
+	ext := "png"
+	switch strings.ToLower(p.screenshotFormat) {
+	case "jpg", "jpeg":
+		format = page.CaptureScreenshotFormatJpeg
+		ext = "jpg"
+	}
+
+	w, h := p.screenshotViewport[0], p.screenshotViewport[1]
+	if w <= 0 || h <= 0 {
+		w, h = 1280, 800
+	}
+
+	var buf []byte
+	err := chromedp.Run(ctx,
+		emulation.SetDeviceMetricsOverride(w, h, 1, false),
+		chromedp.ActionFunc(func(c context.Context) error {
+			var shotErr error
+			buf, shotErr = page.CaptureScreenshot().
+				WithCaptureBeyondViewport(true).
+				WithFormat(format).
+				Do(c)
+			return shotErr
+		}),
+	)
+	if err != nil || len(buf) == 0 {
+		return
+	}
+
+	sum := sha1.Sum([]byte(pageURL))
+	name := hex.EncodeToString(sum[:]) + "." + ext
+	outPath := filepath.Join(p.screenshotDir, name)
+	if err := os.WriteFile(outPath, buf, 0o644); err != nil {
+		return
+	}
+	p.c.emitLine("screenshot", fmt.Sprintf("[screenshot] - %s -> %s", pageURL, outPath))
+}
+
+// feedDiscoveredURL visits+emits a URL observed from a CDP network event,
+// tagging it with `kind` so --types/--exclude-types filtering applies.
+func (p *SsrPool) feedDiscoveredURL(reqURL, kind, prefix string) {
+	if reqURL == "" || !inScopeStr(reqURL, p.c.C.URLFilters) || p.c.urlSet.Duplicate(reqURL) {
+		return
+	}
+	_ = p.c.C.Visit(reqURL)
+	p.c.emitLine(kind, prefix+reqURL)
+
+	if kind == "manifest" {
+		p.parseManifest(reqURL)
+	}
+}
+
+// parseManifest fetches a discovered PWA manifest.json and enqueues its
+// start_url/scope, resolved against the manifest's own URL.
+func (p *SsrPool) parseManifest(manifestURL string) {
+	base, err := url.Parse(manifestURL)
+	if err != nil {
+		return
+	}
+	body, status, err := fetchOnce(p.c.C, manifestURL)
+	if err != nil || status != 200 || len(body) == 0 {
+		return
+	}
+	var m struct {
+		StartURL string `json:"start_url"`
+		Scope    string `json:"scope"`
+	}
+	if json.Unmarshal(body, &m) != nil {
+		return
+	}
+	for _, rel := range []string{m.StartURL, m.Scope} {
+		if rel == "" {
+			continue
+		}
+		abs := FixUrl(base, rel)
+		if abs == "" || !inScopeStr(abs, p.c.C.URLFilters) || p.c.urlSet.Duplicate(abs) {
+			continue
+		}
+		_ = p.c.C.Visit(abs)
+		p.c.emitLine("manifest", "[manifest] - "+abs)
+	}
+}
+
+// Close tears down the shared browser allocator.
+func (p *SsrPool) Close() {
+	p.allocCancel()
+}
+
+// spaProbeJS walks well-known in-memory route tables kept by popular JS
+// frameworks (none of which ever touch the network, so the existing
+// EventRequestWillBeSent capture can't see them), plus preload/prefetch
+// hints and script srcs from the DOM, and a best-effort sweep of common
+// state-dehydration globals for string values that look like absolute
+// paths. Every lookup is guarded by an existence check and a try/catch
+// since a page will only ever match one framework at most.
+const spaProbeJS = `(function(){
+  var routes = [];
+  var add = function(r){ if (r) routes.push(String(r)); };
+
+  try {
+    if (window.__NEXT_DATA__) {
+      add(window.__NEXT_DATA__.page);
+      var bm = window.__NEXT_DATA__.buildManifest;
+      if (bm && bm.pages) { for (var k in bm.pages) add(k); }
+    }
+  } catch(e) {}
+
+  try {
+    if (window.__NUXT__ && window.__NUXT__.routePath) add(window.__NUXT__.routePath);
+  } catch(e) {}
+
+  try {
+    // React Router v6 data routers expose a data router singleton; v5 apps
+    // commonly stash their history/router on a global for devtools.
+    if (window.router && window.router.routes) {
+      window.router.routes.forEach(function(r){ add(r.path); });
+    }
+  } catch(e) {}
+
+  try {
+    if (window.router && window.router.options && window.router.options.routes) {
+      window.router.options.routes.forEach(function(r){ add(r.path); });
+    }
+  } catch(e) {}
+
+  try {
+    if (window.ng && window.ng.getComponent) {
+      // Angular's Router.config isn't reliably reachable from window; skip.
+    }
+  } catch(e) {}
+
+  document.querySelectorAll('link[rel="preload"], link[rel="modulepreload"], link[rel="prefetch"]').forEach(function(l){ add(l.getAttribute('href')); });
+  document.querySelectorAll('script[src]').forEach(function(s){ add(s.getAttribute('src')); });
+
+  var pathLike = /^\/[A-Za-z0-9_\-\/]+$/;
+  var sweep = function(obj){
+    if (!obj || typeof obj !== 'object') return;
+    for (var k in obj) {
+      try {
+        var v = obj[k];
+        if (typeof v === 'string' && pathLike.test(v)) add(v);
+        else if (typeof v === 'object') sweep(v);
+      } catch(e) {}
+    }
+  };
+  try { sweep(window.__INITIAL_STATE__); } catch(e) {}
+  try { sweep(window.__APOLLO_STATE__); } catch(e) {}
+
+  return routes;
+})()`
+
+// runSpaProbe injects the built-in framework probe (and any user-supplied
+// --spa-probe snippet) and feeds discovered route paths back into the
+// crawler, resolved against the current page URL.
+func (p *SsrPool) runSpaProbe(ctx context.Context, pageURL string) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return
+	}
+
+	var found []string
+	if err := chromedp.Run(ctx, chromedp.Evaluate(spaProbeJS, &found)); err != nil {
+		return
+	}
+	if p.extraProbeJS != "" {
+		var extra []string
+		if err := chromedp.Run(ctx, chromedp.Evaluate(p.extraProbeJS, &extra)); err == nil {
+			found = append(found, extra...)
+		}
+	}
+
+	for _, rel := range found {
+		abs := FixUrl(base, rel)
+		if abs == "" || !inScopeStr(abs, p.c.C.URLFilters) || p.c.urlSet.Duplicate(abs) {
+			continue
+		}
+		_ = p.c.C.Visit(abs)
+		p.c.emitLine("spa-route", "[spa-route] - "+abs)
+	}
+}
+
+// interactShimJS installs a recorder that intercepts client-side navigation
+// (history.pushState/replaceState and location.assign) so synthetic clicks
+// can be observed without actually leaving the page, then clicks/focuses up
+// to a budget of candidate elements and dispatches synthetic events on them.
+const interactShimJS = `(function(budget){
+  if (!window.__pfInteract) {
+    window.__pfInteract = { urls: [] };
+    var push = history.pushState, replace = history.replaceState;
+    var record = function(u){ try { window.__pfInteract.urls.push(String(u)); } catch(e){} };
+    history.pushState = function(s,t,u){ record(u); return push.apply(history, arguments); };
+    history.replaceState = function(s,t,u){ record(u); return replace.apply(history, arguments); };
+    var origAssign = location.assign.bind(location);
+    location.assign = function(u){ record(u); };
+  }
+  var els = document.querySelectorAll('a[href], button, [role="button"], [data-href], [onclick]');
+  var n = 0;
+  for (var i = 0; i < els.length && n < budget; i++) {
+    var el = els[i];
+    try {
+      el.dispatchEvent(new MouseEvent('click', {bubbles: true, cancelable: true}));
+      n++;
+    } catch(e) {}
+  }
+  var inputs = document.querySelectorAll('input[type=search]');
+  for (var j = 0; j < inputs.length; j++) {
+    try {
+      inputs[j].focus();
+      inputs[j].value = 'pathfinder';
+      inputs[j].dispatchEvent(new Event('input', {bubbles: true}));
+      inputs[j].dispatchEvent(new Event('change', {bubbles: true}));
+    } catch(e) {}
+  }
+  return window.__pfInteract.urls;
+})(%d)`
+
+// runInteract drives the simulated-interaction pass on the already-loaded
+// page and feeds any recorded SPA-navigation targets back into the crawler.
+// It deliberately never lets the shim actually navigate away - click
+// handlers run against a page whose history methods have been hijacked, so
+// router-driven route changes show up as recorded URLs instead of reloads.
+func (p *SsrPool) runInteract(ctx context.Context, pageURL string) {
+	budget := p.interactBudget
+	if budget <= 0 {
+		budget = 25
+	}
+
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return
+	}
+
+	var recorded []string
+	script := fmt.Sprintf(interactShimJS, budget)
+	if err := chromedp.Run(ctx, chromedp.Evaluate(script, &recorded)); err != nil {
+		return
+	}
+
+	for _, raw := range recorded {
+		abs := FixUrl(base, raw)
+		if abs == "" || !inScopeStr(abs, p.c.C.URLFilters) {
+			continue
+		}
+		if p.c.urlSet.Duplicate(abs) {
+			continue
+		}
+		_ = p.c.C.Visit(abs)
+		p.c.emitLine("interact", "[interact] - "+abs)
+	}
+}
+
+// StartRenderManager spins up a headless Chrome render pool and selectively
+// renders pages. Heuristics: small HTML responses likely to be SPAs, plus the
+// start URL. We block heavy assets (images/css/media/fonts) to keep
+// rendering light. Rendering fans out across `--render-workers` tabs sharing
+// one browser process, with a per-host cap of `--render-per-host` concurrent
+// renders so one slow origin can't starve the rest of the budget.
+func StartRenderManager(c *Crawler, cmd *cobra.Command) {
+	budget, _ := cmd.Flags().GetInt("render-budget")
+	if budget <= 0 {
+		budget = 6
+	}
+	renderTimeout, _ := cmd.Flags().GetInt("render-timeout")
+	perPage := time.Duration(renderTimeout) * time.Second
+	if perPage <= 0 {
+		perPage = 8 * time.Second
+	}
+	workers, _ := cmd.Flags().GetInt("render-workers")
+	perHost, _ := cmd.Flags().GetInt("render-per-host")
+
+	pool := NewSsrPool(c, workers, perHost)
+	pool.interact, _ = cmd.Flags().GetBool("interact")
+	pool.interactBudget, _ = cmd.Flags().GetInt("interact-budget")
+	pool.screenshotDir, _ = cmd.Flags().GetString("screenshot-dir")
+	pool.screenshotFormat, _ = cmd.Flags().GetString("screenshot-format")
+	if vp, _ := cmd.Flags().GetString("screenshot-viewport"); vp != "" {
+		if w, h, ok := parseViewport(vp); ok {
+			pool.screenshotViewport = [2]int64{w, h}
+		}
+	}
+	if pool.screenshotDir != "" {
+		_ = os.MkdirAll(pool.screenshotDir, os.ModePerm)
+	}
+	if profilesCSV, _ := cmd.Flags().GetString("render-profiles"); profilesCSV != "" {
+		pool.profiles = parseRenderProfiles(profilesCSV)
+	}
+	if probeFile, _ := cmd.Flags().GetString("spa-probe"); probeFile != "" {
+		if data, err := os.ReadFile(probeFile); err == nil {
+			pool.extraProbeJS = string(data)
+		} else {
+			Logger.Errorf("Failed to read --spa-probe file: %s", err)
+		}
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < pool.workers; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			pool.worker(id)
+		}(i)
+	}
+	go pool.dispatch(done)
+
+	// Seed with the start URL.
+	pool.TaskChannel <- &RenderTask{URL: c.site.String(), Timeout: perPage}
+
+	// Heuristic: enqueue HTML pages that look small (common for SPA shells).
+	c.C.OnResponse(func(r *colly.Response) {
+		if budget <= 0 {
+			return
+		}
+		ct := strings.ToLower(r.Headers.Get("Content-Type"))
+		if strings.Contains(ct, "text/html") && len(r.Body) < 60*1024 {
+			pool.tryEnqueue(&RenderTask{URL: r.Request.URL.String(), Timeout: perPage})
+		}
+	})
+
+	// Budget enforcement + teardown run on their own goroutine so callers
+	// don't block waiting for the render pass to drain.
+	go func() {
+		for int(atomic.LoadInt64(&pool.renderedUnits)) < budget {
+			time.Sleep(3 * time.Second)
+		}
+		pool.closeMu.Lock()
+		pool.closed = true
+		close(pool.TaskChannel)
+		pool.closeMu.Unlock()
+		close(done)
+		wg.Wait()
+		pool.Close()
+	}()
+}
+
+// Helpers
+func inScopeStr(raw string, filters []*regexp.Regexp) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	return InScope(u, filters)
+}
+
+// parseViewport parses a "WIDTHxHEIGHT" string like "1280x800".
+func parseViewport(s string) (w, h int64, ok bool) {
+	parts := strings.SplitN(strings.ToLower(s), "x", 2)
+	if len(parts) != 2 {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(parts[0], "%d", &w); err != nil {
+		return 0, 0, false
+	}
+	if _, err := fmt.Sscanf(parts[1], "%d", &h); err != nil {
+		return 0, 0, false
+	}
+	return w, h, w > 0 && h > 0
+}
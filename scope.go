@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+/* ============================== Scope policy (primary vs related) ============================== */
+
+// LinkTag identifies why a URL was discovered, so the scope policy and the
+// JSON output can treat "the page itself" (TagPrimary: <a href>, JS route
+// strings) differently from its supporting assets (TagRelated: <img src>,
+// stylesheets, favicons, script files as files rather than as sources of
+// further routes).
+type LinkTag string
+
+const (
+	TagPrimary  LinkTag = "primary"
+	TagRelated  LinkTag = "related"
+	TagExternal LinkTag = "external"
+)
+
+// ScopePolicy decides whether a discovered URL gets fetched, and whether a
+// fetched URL's own outlinks get followed, based on the tag it was found
+// under. TagPrimary URLs must stay inside the crawl's URLFilters exactly as
+// before. TagRelated URLs are additionally allowed outside URLFilters when
+// relatedDepth >= 1 (so an archive has its images/CSS/scripts even when they
+// live on a different host), but are fetched exactly once and never
+// recursed into, so that off-domain assets can't themselves pull the crawl
+// off-site. relatedDepth is a boolean gate, not a hop counter: because
+// related assets are never recursed into, there is no second hop to count -
+// any value >= 1 behaves identically to 1.
+type ScopePolicy struct {
+	includeRelated bool
+	relatedDepth   int
+	relatedSeen    *StringFilter
+}
+
+// NewScopePolicy builds a ScopePolicy from the --scope ("primary" or
+// "primary+related", default "primary+related") and --related-depth flags.
+// --related-depth is an on/off gate (0 disables, >=1 enables), not a hop
+// count - see ScopePolicy's doc comment.
+func NewScopePolicy(scopeSpec string, relatedDepth int) *ScopePolicy {
+	includeRelated := strings.ToLower(strings.TrimSpace(scopeSpec)) != "primary"
+	if relatedDepth < 0 {
+		relatedDepth = 0
+	}
+	return &ScopePolicy{
+		includeRelated: includeRelated,
+		relatedDepth:   relatedDepth,
+		relatedSeen:    NewStringFilter(),
+	}
+}
+
+// Allow reports whether u should be fetched at all for the given tag. An
+// off-scope TagRelated URL is allowed once (per relatedSeen) and is the
+// caller's job to route through a collector with no URLFilters of its own,
+// so it never gets recursed into as if it were in scope.
+func (p *ScopePolicy) Allow(u *url.URL, tag LinkTag, urlFilters []*regexp.Regexp) bool {
+	if InScope(u, urlFilters) {
+		return true
+	}
+	if tag != TagRelated || !p.includeRelated || p.relatedDepth < 1 {
+		return false
+	}
+	return !p.relatedSeen.Duplicate(u.String())
+}
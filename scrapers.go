@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"gopkg.in/yaml.v3"
+)
+
+/* ============================== Content scrapers ============================== */
+
+// ScraperRule is one named extraction rule, run against every response
+// whose optional MIME/URL filters match. Type selects how Pattern is
+// interpreted: "regex" against the decoded body text, "query" as a
+// goquery CSS selector against the HTML, or "jsonpath" as a minimal
+// dotted-path expression against the parsed JSON body.
+type ScraperRule struct {
+	Name    string `json:"name" yaml:"name"`
+	Type    string `json:"type" yaml:"type"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+	Mime    string `json:"mime" yaml:"mime"`
+	URL     string `json:"url" yaml:"url"`
+
+	compiledRE  *regexp.Regexp
+	compiledURL *regexp.Regexp
+}
+
+// builtinScraperRules ship with the binary, covering the high-signal
+// secrets ffuf-style data scrapers usually key on.
+var builtinScraperRules = []ScraperRule{
+	{Name: "emails", Type: "regex", Pattern: `[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`},
+	{Name: "jwt", Type: "regex", Pattern: `eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`},
+	{Name: "gcp-key", Type: "regex", Pattern: `AIza[0-9A-Za-z\-_]{35}`},
+	{Name: "firebase-url", Type: "regex", Pattern: `[a-z0-9-]+\.firebaseio\.com`},
+	{Name: "private-key", Type: "regex", Pattern: `-----BEGIN (?:RSA |EC |OPENSSH |DSA |)PRIVATE KEY-----`},
+}
+
+// LoadScraperRulesFile reads a --scraper-file, sniffing YAML vs JSON off
+// the extension (anything not ending in .json is treated as YAML).
+func LoadScraperRulesFile(path string) ([]ScraperRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var rules []ScraperRule
+	if strings.HasSuffix(strings.ToLower(path), ".json") {
+		err = json.Unmarshal(data, &rules)
+	} else {
+		err = yaml.Unmarshal(data, &rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scraper-file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// selectScraperRules resolves the --scrapers flag ("all", "none", or a
+// comma list of builtin names) against builtinScraperRules, then appends
+// whatever a --scraper-file contributed.
+func selectScraperRules(spec string, fileRules []ScraperRule) []ScraperRule {
+	spec = strings.TrimSpace(strings.ToLower(spec))
+
+	var rules []ScraperRule
+	switch spec {
+	case "", "all":
+		rules = append(rules, builtinScraperRules...)
+	case "none":
+		// deliberately empty
+	default:
+		want := map[string]struct{}{}
+		for _, name := range strings.Split(spec, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				want[name] = struct{}{}
+			}
+		}
+		for _, r := range builtinScraperRules {
+			if _, ok := want[r.Name]; ok {
+				rules = append(rules, r)
+			}
+		}
+	}
+
+	return compileScraperRules(append(rules, fileRules...))
+}
+
+func compileScraperRules(rules []ScraperRule) []ScraperRule {
+	out := make([]ScraperRule, 0, len(rules))
+	for _, r := range rules {
+		switch r.Type {
+		case "regex", "":
+			re, err := regexp.Compile(r.Pattern)
+			if err != nil {
+				Logger.Debugf("scraper %q: bad regex pattern: %s", r.Name, err)
+				continue
+			}
+			r.compiledRE = re
+		case "query", "jsonpath":
+			// pattern is interpreted at match time; nothing to precompile
+		default:
+			Logger.Debugf("scraper %q: unknown type %q, skipping", r.Name, r.Type)
+			continue
+		}
+		if r.URL != "" {
+			if re, err := regexp.Compile(r.URL); err == nil {
+				r.compiledURL = re
+			}
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// RunScraperRules evaluates every rule whose mime/url filters match the
+// response described by contentType/u, emitting each match through emit
+// as (rule name, matched value).
+func RunScraperRules(rules []ScraperRule, body []byte, bodyStr, contentType, u string, emit func(name, value string)) {
+	for _, r := range rules {
+		if r.Mime != "" && !strings.Contains(contentType, r.Mime) {
+			continue
+		}
+		if r.compiledURL != nil && !r.compiledURL.MatchString(u) {
+			continue
+		}
+		switch r.Type {
+		case "regex", "":
+			if r.compiledRE == nil {
+				continue
+			}
+			for _, m := range r.compiledRE.FindAllString(bodyStr, -1) {
+				emit(r.Name, m)
+			}
+		case "query":
+			runQueryScraper(r, body, emit)
+		case "jsonpath":
+			runJSONPathScraper(r, body, emit)
+		}
+	}
+}
+
+func runQueryScraper(r ScraperRule, body []byte, emit func(name, value string)) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	doc.Find(r.Pattern).Each(func(_ int, sel *goquery.Selection) {
+		if text := strings.TrimSpace(sel.Text()); text != "" {
+			emit(r.Name, text)
+		}
+	})
+}
+
+func runJSONPathScraper(r ScraperRule, body []byte, emit func(name, value string)) {
+	var data interface{}
+	if json.Unmarshal(body, &data) != nil {
+		return
+	}
+	for _, v := range evalJSONPath(data, r.Pattern) {
+		emit(r.Name, fmt.Sprintf("%v", v))
+	}
+}
+
+var jsonPathIndexRE = regexp.MustCompile(`\[(\d*)\]`)
+
+// evalJSONPath supports a minimal dotted-path subset: "$.a.b" for object
+// fields, "$.a[]" to fan out over every element of an array, and "$.a[2]"
+// for a specific index. It is not a full JSONPath implementation, just
+// enough to pull values out of typical API responses.
+func evalJSONPath(root interface{}, path string) []interface{} {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return []interface{}{root}
+	}
+
+	cur := []interface{}{root}
+	for _, seg := range strings.Split(path, ".") {
+		if seg == "" {
+			continue
+		}
+		var next []interface{}
+		for _, node := range cur {
+			next = append(next, stepJSONPath(node, seg)...)
+		}
+		cur = next
+	}
+	return cur
+}
+
+func stepJSONPath(node interface{}, seg string) []interface{} {
+	name := seg
+	var indices []string
+	if i := strings.Index(seg, "["); i >= 0 {
+		name = seg[:i]
+		for _, m := range jsonPathIndexRE.FindAllStringSubmatch(seg[i:], -1) {
+			indices = append(indices, m[1])
+		}
+	}
+
+	cur := node
+	if name != "" {
+		m, ok := node.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		v, ok := m[name]
+		if !ok {
+			return nil
+		}
+		cur = v
+	}
+
+	if len(indices) == 0 {
+		return []interface{}{cur}
+	}
+
+	arr, ok := cur.([]interface{})
+	if !ok {
+		return nil
+	}
+	var out []interface{}
+	for _, idx := range indices {
+		if idx == "" {
+			out = append(out, arr...)
+			continue
+		}
+		n, err := strconv.Atoi(idx)
+		if err != nil || n < 0 || n >= len(arr) {
+			continue
+		}
+		out = append(out, arr[n])
+	}
+	return out
+}
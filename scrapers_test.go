@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sort"
+	"testing"
+)
+
+func collectMatches(rules []ScraperRule, body []byte, bodyStr, contentType, u string) map[string][]string {
+	out := map[string][]string{}
+	RunScraperRules(rules, body, bodyStr, contentType, u, func(name, value string) {
+		out[name] = append(out[name], value)
+	})
+	return out
+}
+
+func TestRunScraperRulesRegex(t *testing.T) {
+	rules := compileScraperRules([]ScraperRule{
+		{Name: "emails", Type: "regex", Pattern: `[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`},
+	})
+	body := []byte("contact us at admin@example.com or support@example.org")
+	got := collectMatches(rules, body, string(body), "text/html", "https://example.com/contact")
+	want := []string{"admin@example.com", "support@example.org"}
+	sort.Strings(got["emails"])
+	if len(got["emails"]) != len(want) || got["emails"][0] != want[0] || got["emails"][1] != want[1] {
+		t.Errorf("emails = %v, want %v", got["emails"], want)
+	}
+}
+
+func TestRunScraperRulesQuery(t *testing.T) {
+	rules := compileScraperRules([]ScraperRule{
+		{Name: "titles", Type: "query", Pattern: "h1"},
+	})
+	body := []byte(`<html><body><h1>Hello</h1><h1>World</h1></body></html>`)
+	got := collectMatches(rules, body, string(body), "text/html", "https://example.com/")
+	if len(got["titles"]) != 2 || got["titles"][0] != "Hello" || got["titles"][1] != "World" {
+		t.Errorf("titles = %v, want [Hello World]", got["titles"])
+	}
+}
+
+func TestRunScraperRulesJSONPath(t *testing.T) {
+	rules := compileScraperRules([]ScraperRule{
+		{Name: "names", Type: "jsonpath", Pattern: "$.users[].name"},
+	})
+	body := []byte(`{"users":[{"name":"alice"},{"name":"bob"}]}`)
+	got := collectMatches(rules, body, string(body), "application/json", "https://example.com/api")
+	if len(got["names"]) != 2 || got["names"][0] != "alice" || got["names"][1] != "bob" {
+		t.Errorf("names = %v, want [alice bob]", got["names"])
+	}
+}
+
+func TestRunScraperRulesMimeFilter(t *testing.T) {
+	rules := compileScraperRules([]ScraperRule{
+		{Name: "emails", Type: "regex", Pattern: `[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`, Mime: "application/json"},
+	})
+	body := []byte("admin@example.com")
+	got := collectMatches(rules, body, string(body), "text/html", "https://example.com/")
+	if len(got["emails"]) != 0 {
+		t.Errorf("expected mime filter to suppress match, got %v", got["emails"])
+	}
+}
+
+func TestSelectScraperRulesAllNoneAndSubset(t *testing.T) {
+	all := selectScraperRules("all", nil)
+	if len(all) != len(builtinScraperRules) {
+		t.Errorf("\"all\" = %d rules, want %d", len(all), len(builtinScraperRules))
+	}
+
+	none := selectScraperRules("none", nil)
+	if len(none) != 0 {
+		t.Errorf("\"none\" = %d rules, want 0", len(none))
+	}
+
+	subset := selectScraperRules("emails,jwt", nil)
+	if len(subset) != 2 {
+		t.Fatalf("\"emails,jwt\" = %d rules, want 2", len(subset))
+	}
+	names := map[string]bool{subset[0].Name: true, subset[1].Name: true}
+	if !names["emails"] || !names["jwt"] {
+		t.Errorf("subset names = %v, want emails+jwt", names)
+	}
+}
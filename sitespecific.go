@@ -0,0 +1,121 @@
+package main
+
+import (
+	"encoding/xml"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+)
+
+/* ============================== Site-specific handlers ============================== */
+
+// SiteHandler extracts high-signal artifacts from responses whose host/path
+// matches a well-known pattern (GitHub, S3 bucket listings, YouTube, ...)
+// that a generic LinkFinder regex pass would either miss or only half-parse.
+type SiteHandler struct {
+	Name    string
+	Match   func(u *url.URL) bool
+	Extract func(resp *colly.Response, emit func(kind, url string))
+}
+
+var siteHandlers []SiteHandler
+
+func registerSiteHandler(h SiteHandler) {
+	siteHandlers = append(siteHandlers, h)
+}
+
+// runSiteHandlers invokes every matching handler for resp, forwarding each
+// emitted (kind, url) pair through emit.
+func runSiteHandlers(resp *colly.Response, emit func(kind, url string)) {
+	for _, h := range siteHandlers {
+		if h.Match(resp.Request.URL) {
+			h.Extract(resp, emit)
+		}
+	}
+}
+
+func init() {
+	registerSiteHandler(githubHandler())
+	registerSiteHandler(s3Handler())
+	registerSiteHandler(youtubeHandler())
+}
+
+/* ----- GitHub ----- */
+
+var githubBlobRE = regexp.MustCompile(`^/([^/]+)/([^/]+)/blob/([^/]+)/(.+)$`)
+
+func githubHandler() SiteHandler {
+	return SiteHandler{
+		Name: "github",
+		Match: func(u *url.URL) bool {
+			return strings.EqualFold(u.Hostname(), "github.com")
+		},
+		Extract: func(resp *colly.Response, emit func(kind, url string)) {
+			u := resp.Request.URL
+			if m := githubBlobRE.FindStringSubmatch(u.Path); m != nil {
+				owner, repo, ref, file := m[1], m[2], m[3], m[4]
+				raw := "https://raw.githubusercontent.com/" + owner + "/" + repo + "/" + ref + "/" + file
+				emit("github-raw", raw)
+			}
+			if m := regexp.MustCompile(`^/([^/]+)/([^/]+)/?$`).FindStringSubmatch(u.Path); m != nil {
+				owner, repo := m[1], m[2]
+				emit("github-gitconfig", "https://github.com/"+owner+"/"+repo+"/raw/HEAD/.git/config")
+			}
+		},
+	}
+}
+
+/* ----- S3 bucket listings ----- */
+
+type s3ListBucketResult struct {
+	XMLName xml.Name `xml:"ListBucketResult"`
+	Name    string   `xml:"Name"`
+	Keys    []string `xml:"Contents>Key"`
+}
+
+func s3Handler() SiteHandler {
+	return SiteHandler{
+		Name: "s3",
+		Match: func(u *url.URL) bool {
+			h := strings.ToLower(u.Hostname())
+			return strings.Contains(h, ".s3.") || strings.Contains(h, ".s3-") || strings.HasSuffix(h, "s3.amazonaws.com")
+		},
+		Extract: func(resp *colly.Response, emit func(kind, url string)) {
+			var lb s3ListBucketResult
+			if xml.Unmarshal(resp.Body, &lb) != nil || len(lb.Keys) == 0 {
+				return
+			}
+			base := *resp.Request.URL
+			base.Path = "/"
+			base.RawQuery = ""
+			for _, key := range lb.Keys {
+				emit("s3-key", base.String()+strings.TrimPrefix(key, "/"))
+			}
+		},
+	}
+}
+
+/* ----- YouTube / embeds ----- */
+
+var youtubeIDRE = regexp.MustCompile(`(?:[?&]v=|/embed/|youtu\.be/)([A-Za-z0-9_-]{6,})`)
+
+func youtubeHandler() SiteHandler {
+	return SiteHandler{
+		Name: "youtube",
+		Match: func(u *url.URL) bool {
+			h := strings.ToLower(u.Hostname())
+			return strings.Contains(h, "youtube.com") || h == "youtu.be"
+		},
+		Extract: func(resp *colly.Response, emit func(kind, url string)) {
+			m := youtubeIDRE.FindStringSubmatch(resp.Request.URL.String())
+			if m == nil {
+				return
+			}
+			id := m[1]
+			emit("youtube-video", "https://www.youtube.com/get_video_info?video_id="+id)
+			emit("youtube-video", "https://www.youtube.com/watch?v="+id)
+		},
+	}
+}
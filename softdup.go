@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/bits"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+/* ============================== Soft-404 / duplicate-page suppression ============================== */
+
+// softDupeRingSize bounds how many distinct fingerprint clusters we
+// remember per host, so a long crawl of a single host can't grow this
+// without bound.
+const softDupeRingSize = 64
+
+// softDupeThreshold is how many matches against the same cluster it takes
+// before further matches get treated as template noise rather than content.
+const softDupeThreshold = 5
+
+// softDupeMaxDistance is the SimHash Hamming distance (out of 64 bits)
+// at or below which two pages are considered the same template.
+const softDupeMaxDistance = 3
+
+var tagOpenRE = regexp.MustCompile(`(?i)<\s*([a-z][a-z0-9]*)`)
+var wordRE = regexp.MustCompile(`[A-Za-z0-9]+`)
+var scriptStyleRE = regexp.MustCompile(`(?is)<script[^>]*>.*?</script\s*>|<style[^>]*>.*?</style\s*>`)
+var anyTagRE = regexp.MustCompile(`<[^>]+>`)
+
+// pageFingerprint is what gets compared between same-host pages: a 64-bit
+// SimHash over the tokenized visible text, plus a sorted tag histogram.
+// Two pages match only when their histograms are identical and their
+// SimHashes are within softDupeMaxDistance, so the histogram narrows
+// matches rather than adding new ones.
+type pageFingerprint struct {
+	simhash   uint64
+	structure string
+}
+
+func fingerprintPage(body string) pageFingerprint {
+	return pageFingerprint{
+		simhash:   simhash64(tokenize(body)),
+		structure: tagHistogram(body),
+	}
+}
+
+func tokenize(body string) []string {
+	text := scriptStyleRE.ReplaceAllString(body, " ")
+	text = anyTagRE.ReplaceAllString(text, " ")
+	return wordRE.FindAllString(strings.ToLower(text), -1)
+}
+
+func tagHistogram(body string) string {
+	counts := map[string]int{}
+	for _, m := range tagOpenRE.FindAllStringSubmatch(body, -1) {
+		counts[strings.ToLower(m[1])]++
+	}
+	tags := make([]string, 0, len(counts))
+	for t := range counts {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	parts := make([]string, 0, len(tags))
+	for _, t := range tags {
+		parts = append(parts, fmt.Sprintf("%s:%d", t, counts[t]))
+	}
+	return strings.Join(parts, "|")
+}
+
+// simhash64 builds a 64-bit SimHash by weighting each bit of every token's
+// FNV-1a hash by +1/-1 and keeping the sign of the sum per bit.
+func simhash64(tokens []string) uint64 {
+	var weights [64]int
+	for _, tok := range tokens {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(tok))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+	var out uint64
+	for bit := 0; bit < 64; bit++ {
+		if weights[bit] > 0 {
+			out |= 1 << uint(bit)
+		}
+	}
+	return out
+}
+
+func hamming64(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// fpCluster is one distinct template seen on a host, plus how many pages
+// have matched it so far.
+type fpCluster struct {
+	fp    pageFingerprint
+	count int
+}
+
+// hostFingerprints is a bounded ring of template clusters for one host.
+type hostFingerprints struct {
+	mu       sync.Mutex
+	clusters []fpCluster
+	next     int
+}
+
+// SoftDupeDetector tracks per-host page fingerprints so soft-404 / template
+// pages can be suppressed after the Nth near-identical hit instead of being
+// re-crawled and re-reported as if each were a distinct resource.
+type SoftDupeDetector struct {
+	mu    sync.Mutex
+	hosts map[string]*hostFingerprints
+}
+
+func NewSoftDupeDetector() *SoftDupeDetector {
+	return &SoftDupeDetector{hosts: map[string]*hostFingerprints{}}
+}
+
+// Check fingerprints body against what's already been seen on host and
+// reports whether this page should be treated as a soft-404/template dup.
+// It always records the fingerprint, so clusters keep growing their match
+// count even after crossing the threshold.
+func (d *SoftDupeDetector) Check(host, body string) bool {
+	fp := fingerprintPage(body)
+
+	d.mu.Lock()
+	hf, ok := d.hosts[host]
+	if !ok {
+		hf = &hostFingerprints{clusters: make([]fpCluster, 0, softDupeRingSize)}
+		d.hosts[host] = hf
+	}
+	d.mu.Unlock()
+
+	hf.mu.Lock()
+	defer hf.mu.Unlock()
+
+	for i := range hf.clusters {
+		c := &hf.clusters[i]
+		if c.fp.structure == fp.structure && hamming64(c.fp.simhash, fp.simhash) <= softDupeMaxDistance {
+			c.count++
+			return c.count > softDupeThreshold
+		}
+	}
+
+	entry := fpCluster{fp: fp, count: 1}
+	if len(hf.clusters) < softDupeRingSize {
+		hf.clusters = append(hf.clusters, entry)
+	} else {
+		hf.clusters[hf.next] = entry
+		hf.next = (hf.next + 1) % softDupeRingSize
+	}
+	return false
+}
@@ -3,10 +3,7 @@ package main
 import (
 	"bufio"
 	"crypto/tls"
-	"encoding/json"
-	"encoding/xml"
 	"fmt"
-	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -22,7 +19,9 @@ import (
 	"github.com/gocolly/colly/v2"
 	"github.com/gocolly/colly/v2/extensions"
 	"github.com/mitchellh/go-homedir"
+	"github.com/temoto/robotstxt"
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+	"golang.org/x/net/http2"
 	"golang.org/x/net/publicsuffix"
 
 	"github.com/sirupsen/logrus"
@@ -111,6 +110,13 @@ func (f *EmitFilter) OK(kind string) bool {
 	if _, bad := f.deny[kind]; bad {
 		return false
 	}
+	// softdup is noise by construction (repeated soft-404/template hits);
+	// it only prints when explicitly asked for via --types, never under
+	// allowAll's "everything" default.
+	if kind == "softdup" {
+		_, ok := f.allow[kind]
+		return ok
+	}
 	if f.allowAll {
 		return true
 	}
@@ -130,6 +136,16 @@ func (sf *StringFilter) Duplicate(s string) bool {
 	return loaded
 }
 
+// Len returns the number of distinct strings seen so far.
+func (sf *StringFilter) Len() int {
+	n := 0
+	sf.filter.Range(func(_, _ interface{}) bool {
+		n++
+		return true
+	})
+	return n
+}
+
 /* ============================== Globals for perf/noise ============================== */
 
 // Compile once, reuse both for DisallowedURLFilters and for local skip checks
@@ -140,36 +156,77 @@ const maxGrepBody = 4 * 1024 * 1024 // 4MB
 
 // Singletons for small hot paths
 var newlineRE = regexp.MustCompile(`[\t\r\n]+`)
-var allowDisallowStripRE = regexp.MustCompile(`(?i).*llow:\s*`)
 var decodeReplacer = strings.NewReplacer(`\u002f`, "/", `\u0026`, "&")
 
 /* ============================== Crawler ============================== */
 
-var DefaultHTTPTransport = &http.Transport{
-	DialContext: (&net.Dialer{
-		Timeout:   10 * time.Second,
-		KeepAlive: 30 * time.Second,
-	}).DialContext,
-	MaxIdleConns:    100,
-	MaxConnsPerHost: 1000,
-	IdleConnTimeout: 30 * time.Second,
-	TLSClientConfig: &tls.Config{
-		InsecureSkipVerify: false, // secure by default
-		Renegotiation:      tls.RenegotiateOnceAsClient,
-	},
+// newHTTPTransport builds the transport used for every crawl, tuned by the
+// --http2/--max-idle-conns/--idle-conn-timeout/--dial-timeout/--keepalive/
+// --tls-handshake-timeout flags. Defaults mirror gospider's tuned transport
+// (60s dial, 30s keepalive, unlimited idle conns, 5s idle timeout, 10s TLS
+// handshake) rather than Go's own http.DefaultTransport, since those are
+// the values that hold up under a crawl's connection churn.
+func newHTTPTransport(cmd *cobra.Command) *http.Transport {
+	dialTimeout, _ := cmd.Flags().GetInt("dial-timeout")
+	keepAlive, _ := cmd.Flags().GetInt("keepalive")
+	maxIdleConns, _ := cmd.Flags().GetInt("max-idle-conns")
+	idleConnTimeout, _ := cmd.Flags().GetInt("idle-conn-timeout")
+	tlsHandshakeTimeout, _ := cmd.Flags().GetInt("tls-handshake-timeout")
+
+	t := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   time.Duration(dialTimeout) * time.Second,
+			KeepAlive: time.Duration(keepAlive) * time.Second,
+			DualStack: true,
+		}).DialContext,
+		MaxIdleConns:        maxIdleConns,
+		MaxConnsPerHost:     1000,
+		IdleConnTimeout:     time.Duration(idleConnTimeout) * time.Second,
+		TLSHandshakeTimeout: time.Duration(tlsHandshakeTimeout) * time.Second,
+		TLSClientConfig: &tls.Config{
+			InsecureSkipVerify: false, // secure by default
+			Renegotiation:      tls.RenegotiateOnceAsClient,
+		},
+	}
+
+	if http2Enabled, _ := cmd.Flags().GetBool("http2"); http2Enabled {
+		if err := http2.ConfigureTransport(t); err != nil {
+			Logger.Debugf("http2: %v", err)
+		}
+	}
+
+	return t
 }
 
 type Crawler struct {
 	cmd                 *cobra.Command
 	C                   *colly.Collector
 	LinkFinderCollector *colly.Collector
+	RelatedCollector    *colly.Collector
 	Output              *Output
+	Warc                *WarcWriter
+	Queue               VisitQueue
 
 	subSet  *StringFilter
 	awsSet  *StringFilter
 	jsSet   *StringFilter
 	urlSet  *StringFilter
 	formSet *StringFilter
+	tlsSeen *StringFilter
+
+	softDupe *SoftDupeDetector
+
+	scraperRules []ScraperRule
+	scraperSeen  *StringFilter
+
+	sitemapSeen     *StringFilter
+	sitemapMaxDepth int
+	sitemapSince    time.Time
+
+	scope       *ScopePolicy
+	tagExternal bool
+
+	tlsFingerprintEnabled bool
 
 	site       *url.URL
 	domain     string
@@ -190,6 +247,7 @@ type SpiderOutput struct {
 	Output     string `json:"output"`
 	StatusCode int    `json:"status"`
 	Length     int    `json:"length"`
+	Tag        string `json:"tag,omitempty"`
 }
 
 func (crawler *Crawler) emitLine(kind, line string) {
@@ -226,6 +284,66 @@ func (crawler *Crawler) emitURL(status, length int, u string) {
 	crawler.emitLine("url", out)
 }
 
+// emitSoftDup mirrors emitURL but under the "softdup" kind, used once
+// softDupe has classified a page as a repeated soft-404/template hit.
+func (crawler *Crawler) emitSoftDup(status int, u string) {
+	out := fmt.Sprintf("[softdup] - [code-%d] - %s", status, u)
+	if crawler.JsonOutput {
+		sout := SpiderOutput{Input: crawler.Input, Source: "body", OutputType: "softdup", StatusCode: status, Output: u}
+		if data, err := jsoniter.MarshalToString(sout); err == nil {
+			out = data
+		}
+	} else if crawler.Quiet {
+		out = u
+	}
+	crawler.emitLine("softdup", out)
+}
+
+// emitExternalHref records a hyperlink that fell outside both the crawl's
+// scope and its --related-depth allowance, tagged "external" so JSON
+// consumers can still see what the page linked to without it ever being
+// fetched. Gated behind --tag-external since most crawls don't want the
+// extra noise of every off-scope <a href> on the page.
+func (crawler *Crawler) emitExternalHref(u string) {
+	if crawler.urlSet.Duplicate(u) {
+		return
+	}
+	out := fmt.Sprintf("[href] - %s", u)
+	if crawler.JsonOutput {
+		sout := SpiderOutput{Input: crawler.Input, Source: "body", OutputType: "href", Output: u, Tag: string(TagExternal)}
+		if data, err := jsoniter.MarshalToString(sout); err == nil {
+			out = data
+		}
+	} else if crawler.Quiet {
+		out = u
+	}
+	crawler.emitLine("href", out)
+}
+
+// runScrapers evaluates crawler.scraperRules against one response body and
+// emits every match under its own "scraper:<name>" kind, deduped per value.
+func (crawler *Crawler) runScrapers(body []byte, bodyStr, contentType, u string) {
+	if len(crawler.scraperRules) == 0 {
+		return
+	}
+	RunScraperRules(crawler.scraperRules, body, bodyStr, contentType, u, func(name, value string) {
+		kind := "scraper:" + name
+		if crawler.scraperSeen.Duplicate(kind + ":" + value) {
+			return
+		}
+		out := fmt.Sprintf("[%s] - %s", kind, value)
+		if crawler.JsonOutput {
+			sout := SpiderOutput{Input: crawler.Input, Source: u, OutputType: kind, Output: value}
+			if data, err := jsoniter.MarshalToString(sout); err == nil {
+				out = data
+			}
+		} else if crawler.Quiet {
+			out = value
+		}
+		crawler.emitLine(kind, out)
+	})
+}
+
 // New: third param scopeOverride enables "bare-domain => auto-scope + subs"
 func NewCrawler(site *url.URL, cmd *cobra.Command, scopeOverride *ScopeOverride) *Crawler {
 	domain := GetDomain(site)
@@ -246,20 +364,31 @@ func NewCrawler(site *url.URL, cmd *cobra.Command, scopeOverride *ScopeOverride)
 	// note: subs flag is used only if scopeOverride == nil
 	flagSubs, _ := cmd.Flags().GetBool("subs")
 	filterLengthStr, _ := cmd.Flags().GetString("filter-length")
+	tlsFingerprintEnabled, _ := cmd.Flags().GetBool("tls-fingerprint")
 
 	// optional filter flags
 	typesCSV, _ := cmd.Flags().GetString("types")
 	excludeCSV, _ := cmd.Flags().GetString("exclude-types")
 	emit := NewEmitFilter(typesCSV, excludeCSV)
 
-	c := colly.NewCollector(
+	collectorOpts := []colly.CollectorOption{
 		colly.Async(true),
 		colly.MaxDepth(maxDepth),
-		colly.IgnoreRobotsTxt(),
-	)
+	}
+	ignoreRobots, _ := cmd.Flags().GetBool("ignore-robots")
+	if ignoreRobots {
+		collectorOpts = append(collectorOpts, colly.IgnoreRobotsTxt())
+	}
+	c := colly.NewCollector(collectorOpts...)
+	if !ignoreRobots {
+		// colly.NewCollector defaults IgnoreRobotsTxt to true and there's no
+		// constructor option to turn enforcement back on, so it has to be
+		// set directly here when --ignore-robots wasn't passed.
+		c.IgnoreRobotsTxt = false
+	}
 
 	client := &http.Client{}
-	t := *DefaultHTTPTransport
+	t := newHTTPTransport(cmd)
 
 	// Proxy
 	if proxy, _ := cmd.Flags().GetString("proxy"); proxy != "" {
@@ -281,7 +410,7 @@ func NewCrawler(site *url.URL, cmd *cobra.Command, scopeOverride *ScopeOverride)
 
 	// Redirect policy
 	noRedirect, _ := cmd.Flags().GetBool("no-redirect")
-	client.Transport = &t
+	client.Transport = t
 	if noRedirect {
 		client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
 			tgt := req.URL.Hostname()
@@ -369,6 +498,74 @@ func NewCrawler(site *url.URL, cmd *cobra.Command, scopeOverride *ScopeOverride)
 		output = NewOutput(outputFolder, filename)
 	}
 
+	// Visit queue (persists the frontier for --resume on long crawls)
+	queueSpec, _ := cmd.Flags().GetString("queue")
+	visitQueue, err := NewVisitQueue(queueSpec)
+	if err != nil {
+		Logger.Errorf("Failed to open visit queue %q: %s", queueSpec, err)
+		visitQueue = NewMemoryQueue()
+	}
+	c.OnRequest(func(r *colly.Request) {
+		_ = visitQueue.Push(QueueItem{URL: r.URL.String(), Depth: r.Depth, Referer: r.Headers.Get("Referer")})
+	})
+	c.OnResponse(func(r *colly.Response) {
+		_ = visitQueue.Remove(r.Request.URL.String())
+		_ = visitQueue.MarkVisited(r.Request.URL.String())
+	})
+	c.OnError(func(r *colly.Response, _ error) { _ = visitQueue.Remove(r.Request.URL.String()) })
+
+	// WARC/WACZ archival output. Shared across every crawler instance
+	// pointed at the same --warc path (e.g. multiple targets under
+	// --threads>1), so concurrent workers serialize through one writer
+	// instead of tearing each other's records.
+	var warc *WarcWriter
+	if warcPath, _ := cmd.Flags().GetString("warc"); warcPath != "" {
+		waczPath, _ := cmd.Flags().GetString("wacz")
+		w, err := OpenSharedWarcWriter(warcPath, waczPath)
+		if err != nil {
+			Logger.Errorf("Failed to open WARC file: %s", err)
+		} else {
+			warc = w
+			c.OnRequest(warc.OnRequest)
+			c.OnResponse(warc.OnResponse)
+		}
+	}
+
+	// Content scrapers (built-in secrets/PII rules plus an optional user file)
+	var scraperFileRules []ScraperRule
+	if scraperFile, _ := cmd.Flags().GetString("scraper-file"); scraperFile != "" {
+		rules, err := LoadScraperRulesFile(scraperFile)
+		if err != nil {
+			Logger.Errorf("Failed to load scraper-file %s: %s", scraperFile, err)
+		} else {
+			scraperFileRules = rules
+		}
+	}
+	scrapersSpec, _ := cmd.Flags().GetString("scrapers")
+	scraperRules := selectScraperRules(scrapersSpec, scraperFileRules)
+
+	// Sitemap recursion limits
+	sitemapMaxDepth, _ := cmd.Flags().GetInt("sitemap-max-depth")
+	if sitemapMaxDepth <= 0 {
+		sitemapMaxDepth = 3
+	}
+	var sitemapSince time.Time
+	if sinceStr, _ := cmd.Flags().GetString("sitemap-since"); sinceStr != "" {
+		if t, terr := time.Parse("2006-01-02", sinceStr); terr == nil {
+			sitemapSince = t
+		} else {
+			Logger.Errorf("Failed to parse --sitemap-since %q (want YYYY-MM-DD): %s", sinceStr, terr)
+		}
+	}
+
+	// Scope policy: primary links must stay in URLFilters; related assets
+	// (images, stylesheets, favicons) may be fetched once from outside it,
+	// never recursed into.
+	scopeSpec, _ := cmd.Flags().GetString("scope")
+	relatedDepth, _ := cmd.Flags().GetInt("related-depth")
+	scopePolicy := NewScopePolicy(scopeSpec, relatedDepth)
+	tagExternal, _ := cmd.Flags().GetBool("tag-external")
+
 	// Length filter list
 	var filterLengthSlice []int
 	if filterLengthStr != "" {
@@ -425,11 +622,26 @@ func NewCrawler(site *url.URL, cmd *cobra.Command, scopeOverride *ScopeOverride)
 		c.URLFilters = append(c.URLFilters, scope)
 	}
 
-	// Limits
+	// Limits. robots.txt's Crawl-delay (if it asks for more than --delay)
+	// takes priority, same way Prime()'s own robots.txt fetch is a separate,
+	// lightweight pass ahead of the main async collector. This has to happen
+	// before the Limit call below, since colly's first matching rule wins
+	// and a later one for the same domain would never take effect.
+	reqDelay := time.Duration(delay) * time.Second
+	if robotsFlag, _ := cmd.Flags().GetBool("robots"); robotsFlag {
+		if rbody, rstatus, rerr := fetchOnce(c, site.String()+"/robots.txt"); rerr == nil && rstatus == 200 && len(rbody) > 0 {
+			if rd, rderr := robotstxt.FromStatusAndBytes(rstatus, rbody); rderr == nil {
+				if cd := rd.FindGroup(c.UserAgent).CrawlDelay; cd > reqDelay {
+					reqDelay = cd
+				}
+			}
+		}
+	}
+
 	if err := c.Limit(&colly.LimitRule{
 		DomainGlob:  "*",
 		Parallelism: concurrent,
-		Delay:       time.Duration(delay) * time.Second,
+		Delay:       reqDelay,
 		RandomDelay: time.Duration(randomDelay) * time.Second,
 	}); err != nil {
 		Logger.Errorf("Failed to set Limit Rule: %s", err)
@@ -461,10 +673,28 @@ func NewCrawler(site *url.URL, cmd *cobra.Command, scopeOverride *ScopeOverride)
 		linkFinderCollector.URLFilters = append(linkFinderCollector.URLFilters, c.URLFilters...)
 	}
 
+	// Related-asset collector: fetches TagRelated URLs the main collector's
+	// own URLFilters would otherwise reject outright (colly enforces
+	// URLFilters itself, ScopePolicy can't override that on c directly).
+	// Static extensions are exactly what related assets usually are, so
+	// this one skips disallowedExtRE; it still respects an explicit
+	// --blacklist.
+	relatedC := c.Clone()
+	relatedC.URLFilters = nil
+	relatedC.DisallowedURLFilters = nil
+	if blacklists, _ := cmd.Flags().GetString("blacklist"); blacklists != "" {
+		relatedC.DisallowedURLFilters = append(relatedC.DisallowedURLFilters, regexp.MustCompile(blacklists))
+	}
+	if warc != nil {
+		relatedC.OnRequest(warc.OnRequest)
+		relatedC.OnResponse(warc.OnResponse)
+	}
+
 	return &Crawler{
 		cmd:                 cmd,
 		C:                   c,
 		LinkFinderCollector: linkFinderCollector,
+		RelatedCollector:    relatedC,
 		site:                site,
 		Quiet:               quiet,
 		Input:               site.String(),
@@ -473,11 +703,23 @@ func NewCrawler(site *url.URL, cmd *cobra.Command, scopeOverride *ScopeOverride)
 		raw:                 raw,
 		domain:              domain,
 		Output:              output,
+		Warc:                warc,
+		Queue:               visitQueue,
 		urlSet:              NewStringFilter(),
 		subSet:              NewStringFilter(),
 		jsSet:               NewStringFilter(),
-		formSet:             NewStringFilter(),
-		awsSet:              NewStringFilter(),
+		formSet:               NewStringFilter(),
+		awsSet:                NewStringFilter(),
+		tlsSeen:               NewStringFilter(),
+		softDupe:              NewSoftDupeDetector(),
+		scraperRules:          scraperRules,
+		scraperSeen:           NewStringFilter(),
+		sitemapSeen:           NewStringFilter(),
+		sitemapMaxDepth:       sitemapMaxDepth,
+		sitemapSince:          sitemapSince,
+		scope:                 scopePolicy,
+		tagExternal:           tagExternal,
+		tlsFingerprintEnabled: tlsFingerprintEnabled,
 		filterLengthSlice:   filterLengthSlice,
 		emit:                emit,
 	}
@@ -529,8 +771,60 @@ func fetchOnce(parent *colly.Collector, u string) (body []byte, status int, err
 	return got, code, err
 }
 
+// fetchOnceCT is fetchOnce plus the response's Content-Type, used by
+// primeSitemap to detect gzipped sitemaps that don't advertise it via a
+// ".gz" path suffix. Kept separate from fetchOnce rather than widening its
+// signature, since fetchOnce has several unrelated call sites that don't
+// care about Content-Type.
+func fetchOnceCT(parent *colly.Collector, u string) (body []byte, status int, contentType string, err error) {
+	ch := make(chan struct{}, 1)
+	var got []byte
+	var code int
+	var ct string
+	child := parent.Clone()
+	child.OnResponse(func(r *colly.Response) {
+		got = append(got, r.Body...)
+		code = r.StatusCode
+		ct = r.Headers.Get("Content-Type")
+		select { case ch <- struct{}{}: default: }
+	})
+	child.OnError(func(r *colly.Response, e error) {
+		code = r.StatusCode
+		err = e
+		select { case ch <- struct{}{}: default: }
+	})
+	_ = child.Visit(u)
+	child.Wait()
+	<-ch
+	return got, code, ct, err
+}
+
 /* ============================== Emitting helpers ============================== */
 
+// feedSiteSpecific emits an artifact surfaced by a SiteHandler and visits it
+// through the main collector only if it's inside crawler.C's URLFilters;
+// an off-scope artifact (e.g. a raw.githubusercontent.com URL found while
+// crawling github.com) is emitted but not fetched.
+func (crawler *Crawler) feedSiteSpecific(kind, rawURL string) {
+	if crawler.urlSet.Duplicate(rawURL) {
+		return
+	}
+	out := fmt.Sprintf("[%s] - %s", kind, rawURL)
+	if crawler.JsonOutput {
+		sout := SpiderOutput{Input: crawler.Input, Source: "sitespecific", OutputType: kind, Output: rawURL}
+		if data, err := jsoniter.MarshalToString(sout); err == nil {
+			out = data
+		}
+	} else if crawler.Quiet {
+		out = rawURL
+	}
+	crawler.emitLine(kind, out)
+
+	if abs, err := url.Parse(rawURL); err == nil && InScope(abs, crawler.C.URLFilters) {
+		_ = crawler.C.Visit(rawURL)
+	}
+}
+
 // Always emit JS/asset URLs; only visit when in-scope
 func (crawler *Crawler) feedLinkfinder(jsFileUrl, OutputType, source string) {
 	if crawler.jsSet.Duplicate(jsFileUrl) {
@@ -551,6 +845,7 @@ func (crawler *Crawler) feedLinkfinder(jsFileUrl, OutputType, source string) {
 			Source:     source,
 			OutputType: OutputType,
 			Output:     jsFileUrl,
+			Tag:        string(TagRelated),
 		}
 		if data, err := jsoniter.MarshalToString(sout); err == nil {
 			outputFormat = data
@@ -560,15 +855,23 @@ func (crawler *Crawler) feedLinkfinder(jsFileUrl, OutputType, source string) {
 	}
 	crawler.emitLine("javascript", outputFormat)
 
-	// Only crawl / linkfind when in-scope
-	if !inScope {
+	if inScope {
+		if strings.Contains(jsFileUrl, ".min.js") {
+			originalJS := strings.ReplaceAll(jsFileUrl, ".min.js", ".js")
+			_ = crawler.LinkFinderCollector.Visit(originalJS)
+		}
+		_ = crawler.LinkFinderCollector.Visit(jsFileUrl)
 		return
 	}
-	if strings.Contains(jsFileUrl, ".min.js") {
-		originalJS := strings.ReplaceAll(jsFileUrl, ".min.js", ".js")
-		_ = crawler.LinkFinderCollector.Visit(originalJS)
+
+	// Off-scope <script src> files (OutputType "javascript") get the same
+	// TagRelated treatment as <img>/<link>: fetched once via RelatedCollector
+	// so the asset itself is archived, never parsed for further routes.
+	// Routes discovered inside a JS/HTML body (OutputType "linkfinder") are
+	// page routes, not files, and stay primary-scope-only.
+	if OutputType == "javascript" && crawler.scope.Allow(abs, TagRelated, crawler.C.URLFilters) {
+		_ = crawler.RelatedCollector.Visit(jsFileUrl)
 	}
-	_ = crawler.LinkFinderCollector.Visit(jsFileUrl)
 }
 
 /* ============================== Crawl Start ============================== */
@@ -580,27 +883,50 @@ func (crawler *Crawler) Start(linkfinder bool) {
 
 	uploadFormSet := NewStringFilter()
 
-	crawler.C.OnHTML("a[href], link[href], script[src], form[action], input[type='file']", func(e *colly.HTMLElement) {
+	crawler.C.OnHTML("a[href], link[href], script[src], form[action], input[type='file'], img[src]", func(e *colly.HTMLElement) {
+		if e.Response != nil && e.Response.Ctx != nil && e.Response.Ctx.Get("softdup") == "1" {
+			return // classified as a soft-404/template page; don't recurse into its outlinks
+		}
 		switch e.Name {
-		case "a", "link":
-			urlString := e.Request.AbsoluteURL(e.Attr("href"))
+		case "a", "link", "img":
+			attr := "href"
+			tag := TagPrimary
+			if e.Name == "img" {
+				attr = "src"
+				tag = TagRelated
+			} else if e.Name == "link" {
+				rel := strings.ToLower(e.Attr("rel"))
+				if strings.Contains(rel, "stylesheet") || strings.Contains(rel, "icon") {
+					tag = TagRelated
+				}
+			}
+
+			urlString := e.Request.AbsoluteURL(e.Attr(attr))
 			urlString = FixUrl(crawler.site, urlString)
 			if urlString == "" {
 				return
 			}
-			// Drop static noise early
-			if disallowedExtRE.MatchString(urlString) {
+			// Drop static noise early (primary links only - related assets
+			// like images are exactly the static files this filter targets
+			// for everything else, so skip it here)
+			if tag == TagPrimary && disallowedExtRE.MatchString(urlString) {
 				return
 			}
 			abs, err := url.Parse(urlString)
-			if err != nil || !InScope(abs, crawler.C.URLFilters) {
-				return // drop off-scope before printing
+			if err != nil {
+				return
+			}
+			if !crawler.scope.Allow(abs, tag, crawler.C.URLFilters) {
+				if crawler.tagExternal {
+					crawler.emitExternalHref(canonicalizeURL(abs))
+				}
+				return // drop off-scope before printing; never fetched
 			}
 			urlString = canonicalizeURL(abs)
 			if !crawler.urlSet.Duplicate(urlString) {
 				outputFormat := fmt.Sprintf("[href] - %s", urlString)
 				if crawler.JsonOutput {
-					sout := SpiderOutput{Input: crawler.Input, Source: "body", OutputType: "href", Output: urlString}
+					sout := SpiderOutput{Input: crawler.Input, Source: "body", OutputType: "href", Output: urlString, Tag: string(tag)}
 					if data, err := jsoniter.MarshalToString(sout); err == nil {
 						outputFormat = data
 					}
@@ -608,7 +934,16 @@ func (crawler *Crawler) Start(linkfinder bool) {
 					outputFormat = urlString
 				}
 				crawler.emitLine("href", outputFormat)
-				_ = e.Request.Visit(urlString)
+				if crawler.Warc != nil {
+					crawler.Warc.WriteMetadata(e.Request.URL.String(), urlString)
+				}
+				if InScope(abs, crawler.C.URLFilters) {
+					_ = e.Request.Visit(urlString)
+				} else {
+					// Off-scope TagRelated: fetch once via the unrestricted
+					// related collector, never recursed into.
+					_ = crawler.RelatedCollector.Visit(urlString)
+				}
 			}
 
 		case "form":
@@ -656,6 +991,14 @@ func (crawler *Crawler) Start(linkfinder bool) {
 	})
 
 	crawler.C.OnResponse(func(response *colly.Response) {
+		runSiteHandlers(response, func(kind, emittedURL string) {
+			crawler.feedSiteSpecific(kind, emittedURL)
+		})
+
+		if crawler.tlsFingerprintEnabled && response.Request.URL.Scheme == "https" {
+			go crawler.fingerprintHostOnce(response.Request.URL.Host)
+		}
+
 		body := response.Body
 		u := response.Request.URL.String()
 		bodyLen := len(body)
@@ -669,16 +1012,31 @@ func (crawler *Crawler) Start(linkfinder bool) {
 			decoded = true
 		}
 
+		ct := strings.ToLower(response.Headers.Get("Content-Type"))
+		isHTML := strings.Contains(ct, "text/html") || strings.Contains(ct, "application/xhtml") || ct == ""
+
+		softDup := false
+		if decoded && isHTML && response.StatusCode >= 200 && response.StatusCode < 300 {
+			softDup = crawler.softDupe.Check(response.Request.URL.Host, respStr)
+			if softDup && response.Ctx != nil {
+				response.Ctx.Put("softdup", "1")
+			}
+		}
+
 		if len(crawler.filterLengthSlice) == 0 || !contains(crawler.filterLengthSlice, bodyLen) {
-			crawler.emitURL(response.StatusCode, bodyLen, u)
+			if softDup {
+				crawler.emitSoftDup(response.StatusCode, u)
+			} else {
+				crawler.emitURL(response.StatusCode, bodyLen, u)
+			}
 
-			if decoded && InScope(response.Request.URL, crawler.C.URLFilters) {
+			if decoded && !softDup && InScope(response.Request.URL, crawler.C.URLFilters) {
 				crawler.findSubdomains(respStr)
 				crawler.findAWSS3(respStr)
+				crawler.runScrapers(body, respStr, ct, u)
 
 				// NEW: run LinkFinder on HTML bodies too (catches //images.neopets.com/... from <script src>)
-				ct := strings.ToLower(response.Headers.Get("Content-Type"))
-				if strings.Contains(ct, "text/html") || strings.Contains(ct, "application/xhtml") || ct == "" {
+				if isHTML {
 					paths, err := LinkFinder(respStr)
 					if err == nil && len(paths) > 0 {
 						baseURL := response.Request.URL
@@ -697,7 +1055,7 @@ func (crawler *Crawler) Start(linkfinder bool) {
 								if !crawler.urlSet.Duplicate(absURL) {
 									var out string
 									if crawler.JsonOutput {
-										sout := SpiderOutput{Input: crawler.Input, Source: baseURL.String(), OutputType: "linkfinder", Output: absURL}
+										sout := SpiderOutput{Input: crawler.Input, Source: baseURL.String(), OutputType: "linkfinder", Output: absURL, Tag: string(TagPrimary)}
 										if data, err := jsoniter.MarshalToString(sout); err == nil {
 											out = data
 										}
@@ -734,7 +1092,7 @@ func (crawler *Crawler) Start(linkfinder bool) {
 							if !crawler.urlSet.Duplicate(rebuildURL) {
 								var out string
 								if crawler.JsonOutput {
-									sout := SpiderOutput{Input: crawler.Input, Source: baseURL.String(), OutputType: "linkfinder", Output: rebuildURL}
+									sout := SpiderOutput{Input: crawler.Input, Source: baseURL.String(), OutputType: "linkfinder", Output: rebuildURL, Tag: string(TagPrimary)}
 									if data, err := jsoniter.MarshalToString(sout); err == nil {
 										out = data
 									}
@@ -893,6 +1251,7 @@ func (crawler *Crawler) setupLinkFinder() {
 			if InScope(response.Request.URL, crawler.C.URLFilters) {
 				crawler.findSubdomains(respStr)
 				crawler.findAWSS3(respStr)
+				crawler.runScrapers(body, respStr, response.Headers.Get("Content-Type"), u)
 
 				paths, err := LinkFinder(respStr)
 				if err != nil {
@@ -918,7 +1277,7 @@ func (crawler *Crawler) setupLinkFinder() {
 						if !crawler.urlSet.Duplicate(relPath) {
 							var out string
 							if crawler.JsonOutput {
-								sout := SpiderOutput{Input: crawler.Input, Source: response.Request.URL.String(), OutputType: "linkfinder", Output: relPath}
+								sout := SpiderOutput{Input: crawler.Input, Source: response.Request.URL.String(), OutputType: "linkfinder", Output: relPath, Tag: string(TagPrimary)}
 								if data, err := jsoniter.MarshalToString(sout); err == nil {
 									out = data
 								}
@@ -961,7 +1320,7 @@ func (crawler *Crawler) setupLinkFinder() {
 					if !crawler.urlSet.Duplicate(rebuildURL) {
 						var out string
 						if crawler.JsonOutput {
-							sout := SpiderOutput{Input: crawler.Input, Source: response.Request.URL.String(), OutputType: "linkfinder", Output: rebuildURL}
+							sout := SpiderOutput{Input: crawler.Input, Source: response.Request.URL.String(), OutputType: "linkfinder", Output: rebuildURL, Tag: string(TagPrimary)}
 							if data, err := jsoniter.MarshalToString(sout); err == nil {
 								out = data
 							}
@@ -992,6 +1351,15 @@ func (crawler *Crawler) setupLinkFinder() {
 
 /* ============================== Robots & Sitemap ============================== */
 
+// ParseRobots fetches robots.txt, emits every Allow/Disallow rule that
+// applies to our effective user-agent (falling back to the "*" group) as
+// "robots-allow"/"robots-disallow", feeds every Sitemap: directive into the
+// robust primeSitemap pipeline (gzip + nested sitemapindex support) instead
+// of ParseSiteMap's hardcoded guess list, and visits whatever's Allowed.
+// Actual Disallow enforcement on the crawl frontier itself is handled by
+// colly's own temoto/robotstxt-backed check - c.IgnoreRobotsTxt is set to
+// false in NewCrawler unless --ignore-robots is passed, since colly
+// defaults that to true - this pass is the discovery/emission side of it.
 func ParseRobots(site *url.URL, crawler *Crawler, c *colly.Collector, wg *sync.WaitGroup) {
 	defer wg.Done()
 	robotsURL := site.String() + "/robots.txt"
@@ -1000,352 +1368,158 @@ func ParseRobots(site *url.URL, crawler *Crawler, c *colly.Collector, wg *sync.W
 	if err != nil || status != 200 || len(body) == 0 {
 		return
 	}
-
 	Logger.Infof("Found robots.txt: %s", robotsURL)
-	lines := strings.Split(string(body), "\n")
-
-	for _, line := range lines {
-		if strings.Contains(strings.ToLower(line), "llow:") {
-			u := allowDisallowStripRE.ReplaceAllString(line, "")
-			u = FixUrl(site, strings.TrimSpace(u))
-			if u == "" {
-				continue
-			}
-			outputFormat := fmt.Sprintf("[robots] - %s", u)
-			if crawler.JsonOutput {
-				sout := SpiderOutput{Input: crawler.Input, Source: "robots", OutputType: "url", Output: u}
-				if data, err := jsoniter.MarshalToString(sout); err == nil {
-					outputFormat = data
-				}
-			} else if crawler.Quiet {
-				outputFormat = u
-			}
-			crawler.emitLine("robots", outputFormat)
-			_ = c.Visit(u)
-		}
-	}
-}
-
-type locOnly struct {
-	Loc string `xml:"loc"`
-}
-type urlset struct {
-	URLs []locOnly `xml:"url"`
-}
-type sitemapIndex struct {
-	Maps []locOnly `xml:"sitemap"`
-}
 
-func ParseSiteMap(site *url.URL, crawler *Crawler, c *colly.Collector, wg *sync.WaitGroup) {
-	defer wg.Done()
-	sitemapUrls := []string{
-		"/sitemap.xml", "/sitemap_news.xml", "/sitemap_index.xml", "/sitemap-index.xml", "/sitemapindex.xml",
-		"/sitemap-news.xml", "/post-sitemap.xml", "/page-sitemap.xml", "/portfolio-sitemap.xml", "/home_slider-sitemap.xml",
-		"/category-sitemap.xml", "/author-sitemap.xml",
+	rd, err := robotstxt.FromStatusAndBytes(status, body)
+	if err != nil {
+		Logger.Debugf("Failed to parse robots.txt %s: %s", robotsURL, err)
+		return
 	}
 
-	for _, p := range sitemapUrls {
-		target := site.String() + p
-		Logger.Infof("Trying to find %s", target)
-
-		body, status, err := fetchOnce(c, target)
-		if err != nil || status != 200 || len(body) == 0 {
+	for _, d := range robotsDirectivesForAgent(body, c.UserAgent) {
+		u := FixUrl(site, d.path)
+		if u == "" {
 			continue
 		}
-
-		// Try <urlset>
-		var us urlset
-		if xml.Unmarshal(body, &us) == nil && len(us.URLs) > 0 {
-			for _, e := range us.URLs {
-				loc := strings.TrimSpace(e.Loc)
-				if loc == "" {
-					continue
-				}
-				out := loc
-				if crawler.JsonOutput {
-					sout := SpiderOutput{Input: crawler.Input, Source: "sitemap", OutputType: "url", Output: loc}
-					if data, err := jsoniter.MarshalToString(sout); err == nil {
-						out = data
-					}
-				} else if !crawler.Quiet {
-					out = fmt.Sprintf("[sitemap] - %s", loc)
-				}
-				crawler.emitLine("sitemap", out)
-				_ = c.Visit(loc)
-			}
-			continue
+		kind := "robots-disallow"
+		if d.allow {
+			kind = "robots-allow"
 		}
-
-		// Or <sitemapindex> containing nested sitemaps
-		var si sitemapIndex
-		if xml.Unmarshal(body, &si) == nil && len(si.Maps) > 0 {
-			for _, e := range si.Maps {
-				loc := strings.TrimSpace(e.Loc)
-				if loc == "" {
-					continue
-				}
-				nb, nstatus, nerr := fetchOnce(c, loc)
-				if nerr != nil || nstatus != 200 || len(nb) == 0 {
-					continue
-				}
-				var nus urlset
-				if xml.Unmarshal(nb, &nus) == nil && len(nus.URLs) > 0 {
-					for _, ue := range nus.URLs {
-						u := strings.TrimSpace(ue.Loc)
-						if u == "" {
-							continue
-						}
-						out := u
-						if crawler.JsonOutput {
-							sout := SpiderOutput{Input: crawler.Input, Source: "sitemap", OutputType: "url", Output: u}
-							if data, err := jsoniter.MarshalToString(sout); err == nil {
-								out = data
-							}
-						} else if !crawler.Quiet {
-							out = fmt.Sprintf("[sitemap] - %s", u)
-						}
-						crawler.emitLine("sitemap", out)
-						_ = c.Visit(u)
-					}
-				}
-			}
+		crawler.emitRobotsDirective(kind, u)
+		if d.allow {
+			_ = c.Visit(u)
 		}
 	}
-}
-
-/* ============================== Other sources ============================== */
-
-type wurl struct {
-	date string
-	url  string
-}
-type fetchFn func(string, bool) ([]wurl, error)
 
-func OtherSources(domain string, includeSubs bool) []string {
-	noSubs := !includeSubs
-	var urls []string
-
-	fetchFns := []fetchFn{
-		getWaybackURLs,
-		getCommonCrawlURLs,
-		getVirusTotalURLs,
-		getOtxUrls,
+	for _, sm := range rd.Sitemaps {
+		crawler.emitRobotsDirective("robots-sitemap", sm)
+		crawler.primeSitemap(sm, c, 0)
 	}
-
-	out := make(chan wurl, 256)
-	var wg sync.WaitGroup
-
-	for _, fn := range fetchFns {
-		wg.Add(1)
-		go func(fetch fetchFn) {
-			defer wg.Done()
-			resp, err := fetch(domain, noSubs)
-			if err != nil {
-				Logger.Debugf("Error fetching from source: %v", err)
-				return
-			}
-			for _, r := range resp {
-				if r.url != "" {
-					out <- r
-				}
-			}
-		}(fn)
-	}
-
-	go func() {
-		wg.Wait()
-		close(out)
-	}()
-
-	for w := range out {
-		urls = append(urls, w.url)
-	}
-	return Unique(urls)
 }
 
-func getWaybackURLs(domain string, noSubs bool) ([]wurl, error) {
-	subsWildcard := "*."
-	matchType := "domain"
-	if noSubs {
-		subsWildcard = ""
-		matchType = "host"
-	}
-	res, err := http.Get(
-		fmt.Sprintf("https://web.archive.org/cdx/search/cdx?url=%s%s/*&output=json&fl=timestamp,original&collapse=urlkey&matchType=%s", subsWildcard, domain, matchType),
-	)
-	if err != nil {
-		return []wurl{}, err
-	}
-	defer res.Body.Close()
-
-	raw, err := io.ReadAll(res.Body)
-	if err != nil {
-		return []wurl{}, err
-	}
-
-	var wrapper [][]string
-	if err := json.Unmarshal(raw, &wrapper); err != nil {
-		return []wurl{}, err
-	}
-
-	out := make([]wurl, 0, len(wrapper))
-	skip := true
-	for _, cols := range wrapper {
-		if skip {
-			// first row is a header when output=json (["timestamp","original"])
-			skip = false
-			continue
-		}
-		// Ensure we have at least 2 columns (timestamp, original)
-		if len(cols) < 2 {
-			continue
+func (crawler *Crawler) emitRobotsDirective(kind, u string) {
+	outputFormat := fmt.Sprintf("[%s] - %s", kind, u)
+	if crawler.JsonOutput {
+		sout := SpiderOutput{Input: crawler.Input, Source: "robots", OutputType: kind, Output: u}
+		if data, err := jsoniter.MarshalToString(sout); err == nil {
+			outputFormat = data
 		}
-		out = append(out, wurl{date: cols[0], url: cols[1]})
+	} else if crawler.Quiet {
+		outputFormat = u
 	}
-	return out, nil
+	crawler.emitLine(kind, outputFormat)
 }
 
-func getCommonCrawlURLs(domain string, noSubs bool) ([]wurl, error) {
-	subsWildcard := "*."
-	if noSubs {
-		subsWildcard = ""
-	}
-
-	// First, get the list of available indices
-	indexURL := "https://index.commoncrawl.org/collinfo.json"
-	resp, err := http.Get(indexURL)
-	if err != nil {
-		Logger.Debugf("Failed to fetch CommonCrawl index list: %v", err)
-		return []wurl{}, err
-	}
-	defer resp.Body.Close()
-
-	var indices []struct {
-		ID     string `json:"id"`
-		Name   string `json:"name"`
-		CDXAPI string `json:"cdx-api"`
-	}
-	
-	if err := json.NewDecoder(resp.Body).Decode(&indices); err != nil {
-		Logger.Debugf("Failed to parse CommonCrawl index list: %v", err)
-		return []wurl{}, err
-	}
-
-	if len(indices) == 0 {
-		return []wurl{}, fmt.Errorf("no CommonCrawl indices available")
-	}
-
-	// Use the most recent index (first in the list)
-	latestIndex := indices[0].ID
-	Logger.Debugf("Using CommonCrawl index: %s", latestIndex)
-
-	// Query the latest index
-	queryURL := fmt.Sprintf("https://index.commoncrawl.org/%s?url=%s%s/*&output=json", 
-		latestIndex, subsWildcard, domain)
-	
-	res, err := http.Get(queryURL)
-	if err != nil {
-		return []wurl{}, err
-	}
-	defer res.Body.Close()
-
-	sc := bufio.NewScanner(res.Body)
-	// allow longer lines
-	buf := make([]byte, 1024*1024)
-	sc.Buffer(buf, 10*1024*1024)
-
-	out := make([]wurl, 0)
+type robotsDirective struct {
+	allow bool
+	path  string
+}
 
-	for sc.Scan() {
-		wrapper := struct {
-			URL       string `json:"url"`
-			Timestamp string `json:"timestamp"`
-		}{}
-		if err := json.Unmarshal([]byte(sc.Text()), &wrapper); err != nil {
-			Logger.Debugf("Failed to parse CommonCrawl result: %v", err)
+// robotsDirectivesForAgent walks robots.txt honoring User-agent grouping: a
+// run of Allow/Disallow lines belongs to whichever User-agent line(s)
+// immediately precede it, and the most specific named group matching agent
+// wins over "*", mirroring the semantics temoto/robotstxt applies for actual
+// enforcement. We can't read rules back out of a parsed *robotstxt.Group
+// (its rule list is unexported), so this is a small parallel scan purely
+// for emitting what was found.
+func robotsDirectivesForAgent(body []byte, agent string) []robotsDirective {
+	agent = strings.ToLower(agent)
+
+	type block struct {
+		agents []string
+		lines  []robotsDirective
+	}
+	var blocks []block
+	var cur *block
+	startingNewBlock := true
+
+	for _, raw := range strings.Split(string(body), "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" || strings.HasPrefix(line, "#") {
 			continue
 		}
-		if wrapper.URL != "" {
-			out = append(out, wurl{date: wrapper.Timestamp, url: wrapper.URL})
+		lower := strings.ToLower(line)
+		switch {
+		case strings.HasPrefix(lower, "user-agent:"):
+			name := strings.TrimSpace(line[len("user-agent:"):])
+			if cur == nil || !startingNewBlock {
+				blocks = append(blocks, block{})
+				cur = &blocks[len(blocks)-1]
+			}
+			cur.agents = append(cur.agents, strings.ToLower(name))
+			startingNewBlock = true
+		case strings.HasPrefix(lower, "allow:"), strings.HasPrefix(lower, "disallow:"):
+			if cur == nil {
+				continue
+			}
+			path := strings.TrimSpace(line[strings.Index(line, ":")+1:])
+			if path == "" {
+				continue
+			}
+			cur.lines = append(cur.lines, robotsDirective{allow: strings.HasPrefix(lower, "allow:"), path: path})
+			startingNewBlock = false
 		}
 	}
 
-	if err := sc.Err(); err != nil {
-		Logger.Debugf("Scanner error reading CommonCrawl results: %v", err)
+	var best, wildcard *block
+	bestLen := -1
+	for i := range blocks {
+		b := &blocks[i]
+		for _, a := range b.agents {
+			if a == "*" {
+				wildcard = b
+				continue
+			}
+			if strings.HasPrefix(agent, a) && len(a) > bestLen {
+				bestLen = len(a)
+				best = b
+			}
+		}
 	}
-
-	return out, nil
-}
-
-func getVirusTotalURLs(domain string, _ bool) ([]wurl, error) {
-	out := make([]wurl, 0)
-
-	apiKey := os.Getenv("VT_API_KEY")
-	if apiKey == "" {
-		Logger.Warnf("You are not set VirusTotal API Key yet.")
-		return out, nil
+	if best == nil {
+		best = wildcard
 	}
-
-	fetchURL := fmt.Sprintf("https://www.virustotal.com/vtapi/v2/domain/report?apikey=%s&domain=%s", apiKey, domain)
-	resp, err := http.Get(fetchURL)
-	if err != nil {
-		return out, err
+	if best == nil {
+		return nil
 	}
-	defer resp.Body.Close()
-
-	wrapper := struct {
-		URLs []struct {
-			URL string `json:"url"`
-		} `json:"detected_urls"`
-	}{}
+	return best.lines
+}
 
-	dec := json.NewDecoder(resp.Body)
-	if err := dec.Decode(&wrapper); err != nil {
-		Logger.Debugf("Failed to parse VirusTotal response: %v", err)
-		return out, nil
-	}
-	for _, u := range wrapper.URLs {
-		if u.URL != "" {
-			out = append(out, wurl{url: u.URL})
-		}
-	}
-	return out, nil
+// locOnly is a nested <sitemap> entry inside a <sitemapindex>: it carries
+// a lastmod but (per the protocol) never a changefreq.
+type locOnly struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+type sitemapURLEntry struct {
+	Loc        string `xml:"loc"`
+	LastMod    string `xml:"lastmod"`
+	ChangeFreq string `xml:"changefreq"`
+}
+type urlset struct {
+	URLs []sitemapURLEntry `xml:"url"`
+}
+type sitemapIndex struct {
+	Maps []locOnly `xml:"sitemap"`
 }
 
-func getOtxUrls(domain string, _ bool) ([]wurl, error) {
-	var urls []wurl
-	page := 0
-	maxPages := 10 // Safety limit to prevent infinite loops
+// ParseSiteMap tries the usual guessed sitemap locations and, for whichever
+// exist, hands them to primeSitemap - the same gzip/sitemapindex-recursing
+// engine Prime() and ParseRobots use - so a guessed hit gets identical
+// depth-limiting, cycle detection, and lastmod filtering as a discovered one.
+func ParseSiteMap(site *url.URL, crawler *Crawler, c *colly.Collector, wg *sync.WaitGroup) {
+	defer wg.Done()
+	sitemapUrls := []string{
+		"/sitemap.xml", "/sitemap_news.xml", "/sitemap_index.xml", "/sitemap-index.xml", "/sitemapindex.xml",
+		"/sitemap-news.xml", "/post-sitemap.xml", "/page-sitemap.xml", "/portfolio-sitemap.xml", "/home_slider-sitemap.xml",
+		"/category-sitemap.xml", "/author-sitemap.xml",
+	}
 
-	for page < maxPages {
-		r, err := http.Get(fmt.Sprintf("https://otx.alienvault.com/api/v1/indicators/hostname/%s/url_list?limit=50&page=%d", domain, page))
-		if err != nil {
-			return []wurl{}, err
-		}
-		bytes, err := io.ReadAll(r.Body)
-		r.Body.Close()
-		if err != nil {
-			return []wurl{}, err
-		}
-		wrapper := struct {
-			HasNext bool `json:"has_next"`
-			URLList []struct{ URL string `json:"url"` } `json:"url_list"`
-		}{}
-		if err := json.Unmarshal(bytes, &wrapper); err != nil {
-			Logger.Debugf("Failed to parse AlienVault response: %v", err)
-			return []wurl{}, err
-		}
-		for _, u := range wrapper.URLList {
-			if u.URL != "" {
-				urls = append(urls, wurl{url: u.URL})
-			}
-		}
-		if !wrapper.HasNext {
-			break
-		}
-		page++
+	for _, p := range sitemapUrls {
+		target := site.String() + p
+		Logger.Infof("Trying to find %s", target)
+		crawler.primeSitemap(target, c, 0)
 	}
-	return urls, nil
 }
 
 /* ============================== Grep ============================== */
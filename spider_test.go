@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// newTestCmd builds a *cobra.Command carrying the real flag set (with real
+// defaults), the same way main() does, so NewCrawler sees production flag
+// behavior instead of a hand-picked subset.
+func newTestCmd(t *testing.T) *cobra.Command {
+	t.Helper()
+	cmd := &cobra.Command{Use: "test"}
+	registerFlags(cmd)
+	return cmd
+}
+
+// TestNewCrawlerRespectsIgnoreRobotsDefault guards against colly.NewCollector
+// defaulting IgnoreRobotsTxt to true: NewCrawler must turn enforcement back
+// on unless --ignore-robots was explicitly passed.
+func TestNewCrawlerRespectsIgnoreRobotsDefault(t *testing.T) {
+	site, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("parse site: %v", err)
+	}
+
+	cmd := newTestCmd(t)
+	// This test only cares about the IgnoreRobotsTxt field NewCrawler sets on
+	// the collector, not the separate robots.txt fetch further down in
+	// NewCrawler - disable --robots so that fetch (a live network call
+	// against site, which doesn't exist here) is skipped entirely.
+	if err := cmd.Flags().Set("robots", "false"); err != nil {
+		t.Fatalf("set --robots: %v", err)
+	}
+	crawler := NewCrawler(site, cmd, nil)
+	if crawler.C.IgnoreRobotsTxt {
+		t.Errorf("IgnoreRobotsTxt = true by default; want false so robots.txt Disallow is enforced")
+	}
+}
+
+func TestNewCrawlerIgnoreRobotsFlag(t *testing.T) {
+	site, err := url.Parse("https://example.com")
+	if err != nil {
+		t.Fatalf("parse site: %v", err)
+	}
+
+	cmd := newTestCmd(t)
+	if err := cmd.Flags().Set("ignore-robots", "true"); err != nil {
+		t.Fatalf("set --ignore-robots: %v", err)
+	}
+	// Same reasoning as above: skip NewCrawler's separate robots.txt fetch so
+	// this stays a pure-configuration assertion with no network access.
+	if err := cmd.Flags().Set("robots", "false"); err != nil {
+		t.Fatalf("set --robots: %v", err)
+	}
+	crawler := NewCrawler(site, cmd, nil)
+	if !crawler.C.IgnoreRobotsTxt {
+		t.Errorf("IgnoreRobotsTxt = false with --ignore-robots; want true")
+	}
+}
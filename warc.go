@@ -0,0 +1,302 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gocolly/colly/v2"
+)
+
+/* ============================== WARC output ============================== */
+
+// wacPage is one entry of a WACZ archive's pages/pages.jsonl, the index
+// ReplayWeb.page/pywb use to list what's replayable without scanning the
+// whole WARC.
+type wacPage struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	TS  string `json:"ts"`
+}
+
+// WarcWriter appends WARC 1.1 records for every crawled request/response
+// pair to a single file. It is safe for concurrent use from the async
+// colly callbacks, mirroring the locking pattern used by Output. If path
+// ends in ".gz" each record is written as its own gzip member, the
+// convention WARC readers expect for seekable/streamable .warc.gz files.
+// If waczPath is set, Close also bundles the WARC plus a pages.jsonl and
+// datapackage.json into a WACZ zip.
+type WarcWriter struct {
+	mu       sync.Mutex
+	f        *os.File
+	w        *bufio.Writer
+	path     string
+	gzip     bool
+	waczPath string
+	pages    []wacPage
+	refCount int32
+}
+
+// NewWarcWriter opens (or creates) path and writes a leading warcinfo
+// record describing this crawl. waczPath may be empty to skip WACZ bundling.
+func NewWarcWriter(path, waczPath string) (*WarcWriter, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+	ww := &WarcWriter{
+		f:        f,
+		w:        bufio.NewWriter(f),
+		path:     path,
+		gzip:     strings.HasSuffix(strings.ToLower(path), ".gz"),
+		waczPath: waczPath,
+		refCount: 1,
+	}
+	ww.writeWarcinfo()
+	return ww, nil
+}
+
+var (
+	warcRegistryMu sync.Mutex
+	warcRegistry   = map[string]*WarcWriter{}
+)
+
+// OpenSharedWarcWriter returns the process-wide WarcWriter for path,
+// opening it on first use and retaining (bumping refCount) on every
+// subsequent call for the same path. Crawler workers run one per target
+// concurrently (--threads>1); without this, two targets pointed at the
+// same --warc path would each open an independent *os.File with its own
+// bufio.Writer, and their buffered writes could interleave into torn
+// records. Close() (called once per crawler, same call site as before)
+// only actually flushes/closes/bundles once every holder has released it.
+func OpenSharedWarcWriter(path, waczPath string) (*WarcWriter, error) {
+	warcRegistryMu.Lock()
+	defer warcRegistryMu.Unlock()
+	if ww, ok := warcRegistry[path]; ok {
+		atomic.AddInt32(&ww.refCount, 1)
+		return ww, nil
+	}
+	ww, err := NewWarcWriter(path, waczPath)
+	if err != nil {
+		return nil, err
+	}
+	warcRegistry[path] = ww
+	return ww, nil
+}
+
+func (ww *WarcWriter) writeWarcinfo() {
+	body := fmt.Sprintf("software: pathfinder/%s\r\nformat: WARC File Format 1.1\r\n", VERSION)
+	ww.writeRecord("warcinfo", "", "application/warc-fields", []byte(body))
+}
+
+// WriteMetadata records a discovered outlink as a WARC "metadata" record
+// tied to the page it was found on, so a WACZ/WARC replay can reconstruct
+// the link graph without re-parsing every response body.
+func (ww *WarcWriter) WriteMetadata(sourceURI, outlink string) {
+	body := fmt.Sprintf("outlink: %s\r\n", outlink)
+	ww.writeRecord("metadata", sourceURI, "application/warc-fields", []byte(body))
+}
+
+// OnRequest records the outgoing HTTP request as a WARC "request" record.
+func (ww *WarcWriter) OnRequest(r *colly.Request) {
+	var b []byte
+	b = append(b, fmt.Sprintf("%s %s HTTP/1.1\r\n", r.Method, r.URL.RequestURI())...)
+	b = append(b, fmt.Sprintf("Host: %s\r\n", r.URL.Host)...)
+	if r.Headers != nil {
+		for k, vs := range *r.Headers {
+			for _, v := range vs {
+				b = append(b, fmt.Sprintf("%s: %s\r\n", k, v)...)
+			}
+		}
+	}
+	b = append(b, "\r\n"...)
+	ww.writeRecord("request", r.URL.String(), "application/http; msgtype=request", b)
+}
+
+// OnResponse records the received HTTP response as a WARC "response" record.
+func (ww *WarcWriter) OnResponse(resp *colly.Response) {
+	status := resp.StatusCode
+	var b []byte
+	b = append(b, fmt.Sprintf("HTTP/1.1 %d %s\r\n", status, http.StatusText(status))...)
+	if resp.Headers != nil {
+		for k, vs := range *resp.Headers {
+			for _, v := range vs {
+				b = append(b, fmt.Sprintf("%s: %s\r\n", k, v)...)
+			}
+		}
+	}
+	b = append(b, "\r\n"...)
+	b = append(b, resp.Body...)
+	ww.writeRecord("response", resp.Request.URL.String(), "application/http; msgtype=response", b)
+
+	if ww.waczPath != "" && status >= 200 && status < 300 {
+		ww.mu.Lock()
+		ww.pages = append(ww.pages, wacPage{
+			ID:  newWarcID(),
+			URL: resp.Request.URL.String(),
+			TS:  time.Now().UTC().Format(time.RFC3339),
+		})
+		ww.mu.Unlock()
+	}
+}
+
+func (ww *WarcWriter) writeRecord(warcType, targetURI, contentType string, body []byte) {
+	var rec strings.Builder
+	fmt.Fprintf(&rec, "WARC/1.1\r\n")
+	fmt.Fprintf(&rec, "WARC-Type: %s\r\n", warcType)
+	if targetURI != "" {
+		fmt.Fprintf(&rec, "WARC-Target-URI: %s\r\n", targetURI)
+	}
+	fmt.Fprintf(&rec, "WARC-Date: %s\r\n", time.Now().UTC().Format(time.RFC3339))
+	fmt.Fprintf(&rec, "WARC-Record-ID: <urn:uuid:%s>\r\n", newWarcID())
+	fmt.Fprintf(&rec, "Content-Type: %s\r\n", contentType)
+	fmt.Fprintf(&rec, "Content-Length: %d\r\n", len(body))
+	fmt.Fprintf(&rec, "\r\n")
+	rec.Write(body)
+	fmt.Fprintf(&rec, "\r\n\r\n")
+
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	if ww.gzip {
+		gz := gzip.NewWriter(ww.w)
+		_, _ = gz.Write([]byte(rec.String()))
+		_ = gz.Close()
+		return
+	}
+	ww.w.WriteString(rec.String())
+}
+
+// Close releases this holder's reference; the underlying file is only
+// flushed, closed, and (if waczPath was set) bundled into a WACZ zip once
+// every crawler sharing this writer (see OpenSharedWarcWriter) has closed.
+// The decrement and the registry delete-or-keep decision happen under the
+// same warcRegistryMu as OpenSharedWarcWriter's lookup/retain, so a
+// concurrent Open can't observe the entry still registered, bump refCount
+// back up, and be handed a writer whose file is mid-close.
+func (ww *WarcWriter) Close() {
+	warcRegistryMu.Lock()
+	if atomic.AddInt32(&ww.refCount, -1) > 0 {
+		warcRegistryMu.Unlock()
+		return
+	}
+	delete(warcRegistry, ww.path)
+	warcRegistryMu.Unlock()
+
+	ww.mu.Lock()
+	_ = ww.w.Flush()
+	_ = ww.f.Close()
+	ww.mu.Unlock()
+
+	if ww.waczPath == "" {
+		return
+	}
+	if err := ww.writeWACZ(); err != nil {
+		Logger.Debugf("wacz: %v", err)
+	}
+}
+
+// writeWACZ bundles the already-closed WARC file plus a pages.jsonl and a
+// minimal datapackage.json manifest into a WACZ zip, so the crawl can be
+// replayed directly in pywb/ReplayWeb.page without a separate conversion
+// step.
+func (ww *WarcWriter) writeWACZ() error {
+	warcData, err := os.ReadFile(ww.path)
+	if err != nil {
+		return err
+	}
+
+	zf, err := os.Create(ww.waczPath)
+	if err != nil {
+		return err
+	}
+	defer zf.Close()
+	zw := zip.NewWriter(zf)
+
+	archiveName := "archive/" + filepath.Base(ww.path)
+	aw, err := zw.Create(archiveName)
+	if err != nil {
+		return err
+	}
+	if _, err := aw.Write(warcData); err != nil {
+		return err
+	}
+
+	pw, err := zw.Create("pages/pages.jsonl")
+	if err != nil {
+		return err
+	}
+	for _, p := range ww.pages {
+		line, err := json.Marshal(p)
+		if err != nil {
+			continue
+		}
+		if _, err := pw.Write(append(line, '\n')); err != nil {
+			return err
+		}
+	}
+
+	sum := sha256.Sum256(warcData)
+	dp := struct {
+		Profile     string `json:"profile"`
+		WaczVersion string `json:"wacz_version"`
+		Software    string `json:"software"`
+		Created     string `json:"created"`
+		Resources   []struct {
+			Name  string `json:"name"`
+			Path  string `json:"path"`
+			Hash  string `json:"hash"`
+			Bytes int    `json:"bytes"`
+		} `json:"resources"`
+	}{
+		Profile:     "data-package",
+		WaczVersion: "1.1.1",
+		Software:    fmt.Sprintf("pathfinder/%s", VERSION),
+		Created:     time.Now().UTC().Format(time.RFC3339),
+	}
+	dp.Resources = append(dp.Resources, struct {
+		Name  string `json:"name"`
+		Path  string `json:"path"`
+		Hash  string `json:"hash"`
+		Bytes int    `json:"bytes"`
+	}{
+		Name:  filepath.Base(ww.path),
+		Path:  archiveName,
+		Hash:  "sha256:" + hex.EncodeToString(sum[:]),
+		Bytes: len(warcData),
+	})
+
+	dpw, err := zw.Create("datapackage.json")
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(dpw)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(dp); err != nil {
+		return err
+	}
+
+	return zw.Close()
+}
+
+// newWarcID generates a random v4-style UUID for WARC-Record-ID. A real UUID
+// library isn't worth a new dependency for an identifier whose only
+// requirement is global uniqueness within the archive.
+func newWarcID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
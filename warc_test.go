@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gocolly/colly/v2"
+)
+
+func readWarcRecords(t *testing.T, path string, gz bool) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read %s: %v", path, err)
+	}
+
+	var raw string
+	if gz {
+		r, err := gzip.NewReader(strings.NewReader(string(data)))
+		if err != nil {
+			t.Fatalf("gzip member 1: %v", err)
+		}
+		var sb strings.Builder
+		buf := make([]byte, 4096)
+		for {
+			n, rerr := r.Read(buf)
+			sb.Write(buf[:n])
+			if rerr != nil {
+				break
+			}
+		}
+		raw = sb.String()
+	} else {
+		raw = string(data)
+	}
+
+	return strings.Split(strings.TrimSpace(raw), "WARC/1.1\r\n")
+}
+
+func TestWarcWriterRecordFraming(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.warc")
+
+	ww, err := NewWarcWriter(path, "")
+	if err != nil {
+		t.Fatalf("NewWarcWriter: %v", err)
+	}
+
+	u, _ := url.Parse("https://example.com/page")
+	req := &colly.Request{URL: u, Method: "GET"}
+	ww.OnRequest(req)
+
+	resp := &colly.Response{
+		StatusCode: 200,
+		Body:       []byte("hello world"),
+		Request:    &colly.Request{URL: u},
+	}
+	ww.OnResponse(resp)
+	ww.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open output: %v", err)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	var lines []string
+	for sc.Scan() {
+		lines = append(lines, sc.Text())
+	}
+	content := strings.Join(lines, "\n")
+
+	for _, want := range []string{
+		"WARC-Type: warcinfo",
+		"WARC-Type: request",
+		"WARC-Type: response",
+		"WARC-Target-URI: https://example.com/page",
+		"Content-Length:",
+		"GET /page HTTP/1.1",
+		"HTTP/1.1 200 OK",
+		"hello world",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("output missing %q", want)
+		}
+	}
+}
+
+func TestWarcWriterGzipPerRecordFraming(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.warc.gz")
+
+	ww, err := NewWarcWriter(path, "")
+	if err != nil {
+		t.Fatalf("NewWarcWriter: %v", err)
+	}
+	ww.Close()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open output: %v", err)
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("first record isn't a valid gzip member: %v", err)
+	}
+	buf := make([]byte, 4096)
+	n, _ := gz.Read(buf)
+	if !strings.Contains(string(buf[:n]), "WARC-Type: warcinfo") {
+		t.Errorf("decompressed first record missing warcinfo, got %q", string(buf[:n]))
+	}
+}
+
+func TestOpenSharedWarcWriterReusesEntryAndClosesOnceReleased(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "shared.warc")
+
+	a, err := OpenSharedWarcWriter(path, "")
+	if err != nil {
+		t.Fatalf("open a: %v", err)
+	}
+	b, err := OpenSharedWarcWriter(path, "")
+	if err != nil {
+		t.Fatalf("open b: %v", err)
+	}
+	if a != b {
+		t.Fatal("OpenSharedWarcWriter returned distinct writers for the same path")
+	}
+
+	a.Close()
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("file should still exist while b holds a reference: %v", err)
+	}
+
+	u, _ := url.Parse("https://example.com/")
+	b.OnResponse(&colly.Response{StatusCode: http.StatusOK, Body: []byte("ok"), Request: &colly.Request{URL: u}})
+	b.Close()
+
+	warcRegistryMu.Lock()
+	_, stillRegistered := warcRegistry[path]
+	warcRegistryMu.Unlock()
+	if stillRegistered {
+		t.Error("writer should be removed from the registry once every holder has closed")
+	}
+}